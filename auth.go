@@ -0,0 +1,240 @@
+package otelog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	collector "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// Token is an access token sent as a bearer token to the collector.
+type Token struct {
+	AccessToken string
+	// Expiry is when the token expires. A zero Expiry means the
+	// token never expires.
+	Expiry time.Time
+}
+
+// A TokenSource provides the access tokens sent to the collector.
+type TokenSource interface {
+	Token(ctx context.Context) (Token, error)
+}
+
+// TokenSourceFunc is a function used as a TokenSource.
+type TokenSourceFunc func(ctx context.Context) (Token, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (Token, error) {
+	return f(ctx)
+}
+
+// StaticToken returns a TokenSource always providing token.
+func StaticToken(token string) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (Token, error) {
+		return Token{AccessToken: token}, nil
+	})
+}
+
+// tokenExpiryDelta is how long before their expiry cached tokens are
+// refreshed.
+const tokenExpiryDelta = 30 * time.Second
+
+// cachingTokenSource caches the token of source until shortly before
+// its expiry.
+type cachingTokenSource struct {
+	source TokenSource
+
+	mx    sync.Mutex
+	token Token
+	now   func() time.Time
+}
+
+func newCachingTokenSource(source TokenSource) *cachingTokenSource {
+	return &cachingTokenSource{source: source, now: time.Now}
+}
+
+func (s *cachingTokenSource) valid() bool {
+	if len(s.token.AccessToken) == 0 {
+		return false
+	}
+	return s.token.Expiry.IsZero() || s.now().Add(tokenExpiryDelta).Before(s.token.Expiry)
+}
+
+func (s *cachingTokenSource) Token(ctx context.Context) (Token, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.valid() == true {
+		return s.token, nil
+	}
+	token, err := s.source.Token(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// invalidate discards the cached token, so the next call to Token()
+// fetches a new one.
+func (s *cachingTokenSource) invalidate() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.token = Token{}
+}
+
+// invalidateToken discards the token cached by source, if any. It is
+// called when the collector rejects a token before its expiry, for
+// example because it was revoked.
+func invalidateToken(source TokenSource) {
+	if s, ok := source.(*cachingTokenSource); ok == true {
+		s.invalidate()
+	}
+}
+
+// tokenRequestTimeout is the timeout of the requests fetching tokens
+// with the OAuth2 client credentials grant.
+const tokenRequestTimeout = 10 * time.Second
+
+// clientCredentialsSource fetches tokens with the OAuth2 client
+// credentials grant.
+type clientCredentialsSource struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	client       *http.Client
+}
+
+func (s *clientCredentialsSource) Token(ctx context.Context) (Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.scopes) > 0 {
+		form.Set("scope", strings.Join(s.scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Token{}, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Token{}, fmt.Errorf("otelog: could not fetch token from %s: %s", s.tokenURL, resp.Status)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return Token{}, fmt.Errorf("otelog: invalid token response from %s: %w", s.tokenURL, err)
+	}
+	if len(body.AccessToken) == 0 {
+		return Token{}, fmt.Errorf("otelog: no access token in response from %s", s.tokenURL)
+	}
+	if len(body.TokenType) > 0 && strings.EqualFold(body.TokenType, "bearer") == false {
+		return Token{}, fmt.Errorf("otelog: unsupported token type %q from %s", body.TokenType, s.tokenURL)
+	}
+	res := Token{AccessToken: body.AccessToken}
+	if body.ExpiresIn > 0 {
+		res.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return res, nil
+}
+
+// tokenCredentials sends the tokens of a TokenSource as gRPC per-RPC
+// credentials.
+type tokenCredentials struct {
+	source        TokenSource
+	allowInsecure bool
+}
+
+func (opts logExporterOptions) tokenCredentials() tokenCredentials {
+	return tokenCredentials{source: opts.tokens, allowInsecure: opts.allowInsecureTokens}
+}
+
+func (c tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token.AccessToken}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return c.allowInsecure == false
+}
+
+// authLogsClient adds per-RPC credentials to the calls of a client
+// on a connection it did not dial.
+type authLogsClient struct {
+	client      collector.LogsServiceClient
+	credentials credentials.PerRPCCredentials
+}
+
+func (c authLogsClient) Export(ctx context.Context, in *collector.ExportLogsServiceRequest, opts ...grpc.CallOption) (*collector.ExportLogsServiceResponse, error) {
+	return c.client.Export(ctx, in, append(opts, grpc.PerRPCCredentials(c.credentials))...)
+}
+
+// invalidatingLogsClient discards the cached token when a call is
+// rejected as unauthenticated, so the next call uses a new token.
+type invalidatingLogsClient struct {
+	client collector.LogsServiceClient
+	tokens TokenSource
+}
+
+func (c invalidatingLogsClient) Export(ctx context.Context, in *collector.ExportLogsServiceRequest, opts ...grpc.CallOption) (*collector.ExportLogsServiceResponse, error) {
+	res, err := c.client.Export(ctx, in, opts...)
+	if status.Code(err) == codes.Unauthenticated {
+		invalidateToken(c.tokens)
+	}
+	return res, err
+}
+
+var errInsecureToken = errors.New("otelog: refusing to send tokens over an insecure transport, see WithInsecureTokens()")
+
+// checkTokenTransport returns an error if tokens would be sent over an
+// insecure transport, and that was not allowed.
+func checkTokenTransport(opts logExporterOptions) error {
+	if opts.tokens == nil || opts.allowInsecureTokens == true {
+		return nil
+	}
+	switch {
+	case opts.writer != nil || len(opts.filePath) > 0:
+		return nil
+	case len(opts.httpEndpoint) > 0:
+		if strings.HasPrefix(strings.ToLower(opts.httpEndpoint), "https://") == false {
+			return errInsecureToken
+		}
+	case opts.conn != nil:
+		// the security of the connection is checked by gRPC on each
+		// call, see tokenCredentials.RequireTransportSecurity().
+	case opts.certificates != nil:
+		// TLS is always used with client certificates.
+	default:
+		if opts.credential == nil || opts.credential.Info().SecurityProtocol == "insecure" {
+			return errInsecureToken
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package otelog
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	collector "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCachingTokenSource(t *testing.T) {
+	now := time.Now()
+	fetched := 0
+	source := newCachingTokenSource(TokenSourceFunc(func(ctx context.Context) (Token, error) {
+		fetched++
+		return Token{AccessToken: "token", Expiry: now.Add(time.Minute)}, nil
+	}))
+	source.now = func() time.Time { return now }
+
+	for _, elapsed := range []time.Duration{0, 20 * time.Second, 45 * time.Second} {
+		now = now.Add(elapsed)
+		if _, err := source.Token(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	// the last token is refreshed as it expires in less than
+	// tokenExpiryDelta.
+	if fetched != 2 {
+		t.Errorf("fetched %d tokens, wants 2", fetched)
+	}
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "client" || secret != "secret" ||
+			r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "logs.write admin" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer tokenServer.Close()
+
+	authorization := make(chan string, 1)
+	logsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization <- r.Header.Get("Authorization")
+	}))
+	defer logsServer.Close()
+
+	opts := newOtelLogExporterOptions(
+		WithHTTPEndpoint(logsServer.URL),
+		WithOAuth2ClientCredentials(tokenServer.URL, "client", "secret", []string{"logs.write", "admin"}))
+	client, _, err := newLogsClient(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	client.(*httpLogsClient).client = logsServer.Client()
+	if source := opts.tokens.(*cachingTokenSource).source.(*clientCredentialsSource); source.client.Timeout <= 0 {
+		t.Errorf("token requests have no timeout")
+	}
+
+	if _, err := client.Export(context.Background(), newTestRequest("hello")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := <-authorization; got != "Bearer access" {
+		t.Errorf("authorization = %q, wants %q", got, "Bearer access")
+	}
+
+	failing := &clientCredentialsSource{tokenURL: tokenServer.URL, clientID: "client", client: http.DefaultClient}
+	if _, err := failing.Token(context.Background()); err == nil {
+		t.Errorf("expected an error for invalid client credentials")
+	}
+}
+
+func TestCheckTokenTransport(t *testing.T) {
+	token := WithBearerToken(StaticToken("token"))
+	testdata := []struct {
+		Name     string
+		Options  []LogExporterOption
+		Insecure bool
+	}{
+		{"no token", []LogExporterOption{WithInsecure()}, false},
+		{"grpc insecure", []LogExporterOption{token, WithInsecure()}, true},
+		{"grpc default", []LogExporterOption{token}, true},
+		{"grpc tls", []LogExporterOption{token, WithTLSCredentials(credentials.NewTLS(nil))}, false},
+		{"http", []LogExporterOption{token, WithHTTPEndpoint("http://localhost:4318/v1/logs")}, true},
+		{"https", []LogExporterOption{token, WithHTTPEndpoint("https://localhost:4318/v1/logs")}, false},
+		{"allowed", []LogExporterOption{token, WithInsecure(), WithInsecureTokens()}, false},
+	}
+
+	for _, d := range testdata {
+		err := checkTokenTransport(newOtelLogExporterOptions(d.Options...))
+		if (err != nil) != d.Insecure {
+			t.Errorf("%s: got error %v, wants an error: %t", d.Name, err, d.Insecure)
+		}
+	}
+}
+
+type testLogsServer struct {
+	collector.UnimplementedLogsServiceServer
+	authorization chan []string
+}
+
+func (s *testLogsServer) Export(ctx context.Context, req *collector.ExportLogsServiceRequest) (*collector.ExportLogsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.authorization <- md.Get("authorization")
+	return &collector.ExportLogsServiceResponse{}, nil
+}
+
+func TestBearerTokenGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	server := grpc.NewServer()
+	logsServer := &testLogsServer{authorization: make(chan []string, 2)}
+	collector.RegisterLogsServiceServer(server, logsServer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer conn.Close()
+
+	testdata := [][]LogExporterOption{
+		{WithEndpoint(listener.Addr().String()), WithInsecure()},
+		{WithGRPCConn(conn)},
+	}
+	for _, options := range testdata {
+		options = append(options, WithBearerToken(StaticToken("token")), WithInsecureTokens(), WithSyncer())
+		exporter, err := NewLogExporter(options...)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		exporter.Export(newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "hello"))
+		if got := <-logsServer.authorization; len(got) != 1 || got[0] != "Bearer token" {
+			t.Errorf("authorization = %v, wants %q", got, "Bearer token")
+		}
+		exporter.Shutdown(context.Background())
+	}
+}
+
+// rejectingLogsServer rejects the first call as unauthenticated.
+type rejectingLogsServer struct {
+	collector.UnimplementedLogsServiceServer
+	calls int
+}
+
+func (s *rejectingLogsServer) Export(ctx context.Context, req *collector.ExportLogsServiceRequest) (*collector.ExportLogsServiceResponse, error) {
+	s.calls++
+	if s.calls == 1 {
+		return nil, status.Error(codes.Unauthenticated, "token revoked")
+	}
+	return &collector.ExportLogsServiceResponse{}, nil
+}
+
+func TestBearerTokenInvalidated(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	server := grpc.NewServer()
+	collector.RegisterLogsServiceServer(server, &rejectingLogsServer{})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	calls := 0
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer httpServer.Close()
+
+	testdata := []LogExporterOption{
+		WithEndpoint(listener.Addr().String()),
+		WithHTTPEndpoint(httpServer.URL),
+	}
+	for _, endpoint := range testdata {
+		fetched := 0
+		opts := newOtelLogExporterOptions(endpoint, WithInsecure(), WithInsecureTokens(),
+			WithBearerToken(TokenSourceFunc(func(ctx context.Context) (Token, error) {
+				fetched++
+				return Token{AccessToken: "token"}, nil
+			})))
+		client, closer, err := newLogsClient(opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, err := client.Export(context.Background(), newTestRequest("hello")); err == nil {
+			t.Errorf("expected an error for a rejected token")
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := client.Export(context.Background(), newTestRequest("hello")); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		}
+		if fetched != 2 {
+			t.Errorf("fetched %d tokens, wants 2", fetched)
+		}
+		if closer != nil {
+			closer.Close()
+		}
+	}
+}
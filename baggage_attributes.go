@@ -0,0 +1,56 @@
+package otelog
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// BaggageAttributes selects the W3C baggage members copied into
+// records attributes.
+type BaggageAttributes struct {
+	// Keys is the allowlist of copied members. Other members are
+	// ignored.
+	Keys []string
+	// Prefix is prepended to the member keys to build attribute keys.
+	Prefix string
+	// MaxValueLength is the maximal length in bytes of copied values.
+	// Longer values are left out. Zero or less means unlimited.
+	MaxValueLength int
+}
+
+// NewBaggageAttributes returns BaggageAttributes copying the members
+// keys, without prefix, and with a MaxValueLength of 256.
+func NewBaggageAttributes(keys ...string) BaggageAttributes {
+	return BaggageAttributes{
+		Keys:           keys,
+		MaxValueLength: 256,
+	}
+}
+
+// Merge adds the allowed members of the baggage of ctx to record. As
+// for MergeContextAttributes(), attributes already in record take
+// precedence.
+func (b BaggageAttributes) Merge(ctx context.Context, record *logs.LogRecord) {
+	bag := baggage.FromContext(ctx)
+	if bag.Len() == 0 {
+		return
+	}
+	for _, k := range b.Keys {
+		member := bag.Member(k)
+		if len(member.Key()) == 0 {
+			continue
+		}
+		value := member.Value()
+		if b.MaxValueLength > 0 && len(value) > b.MaxValueLength {
+			continue
+		}
+		key := b.Prefix + k
+		if findAttribute(record.Attributes, key) != nil {
+			continue
+		}
+		record.Attributes = append(record.Attributes, &common.KeyValue{Key: key, Value: stringValue(value)})
+	}
+}
@@ -0,0 +1,69 @@
+package otelog
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestBaggageAttributes_Merge(t *testing.T) {
+	var members []baggage.Member
+	for k, v := range map[string]string{
+		"customer.tier": "gold",
+		"experiment.id": strings.Repeat("x", 20),
+		"session.id":    "secret",
+		"region":        "eu",
+	} {
+		m, err := baggage.NewMember(k, v)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		members = append(members, m)
+	}
+	bag, err := baggage.New(members...)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	b := NewBaggageAttributes("customer.tier", "experiment.id", "region", "missing")
+	b.Prefix = "baggage."
+	b.MaxValueLength = 10
+
+	record := newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "hello",
+		&common.KeyValue{Key: "baggage.region", Value: stringValue("us")})
+	b.Merge(ctx, record)
+
+	expected := map[string]string{"baggage.customer.tier": "gold", "baggage.region": "us"}
+	if len(record.Attributes) != len(expected) {
+		t.Errorf("attributes = %v, wants %v", record.Attributes, expected)
+	}
+	for k, v := range expected {
+		if kv := findAttribute(record.Attributes, k); kv.GetValue().GetStringValue() != v {
+			t.Errorf("attribute %s = %v, wants %q", k, kv, v)
+		}
+	}
+}
+
+func TestBaggageAttributes_zeroValue(t *testing.T) {
+	m, err := baggage.NewMember("customer.tier", "gold")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	bag, err := baggage.New(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	record := newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "hello")
+	BaggageAttributes{Keys: []string{"customer.tier"}}.Merge(ctx, record)
+
+	if kv := findAttribute(record.Attributes, "customer.tier"); kv == nil || kv.Value.GetStringValue() != "gold" {
+		t.Errorf("customer.tier = %v, wants %q", kv, "gold")
+	}
+}
@@ -0,0 +1,172 @@
+package otelog
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// certificateFiles loads a client certificate and a CA from files,
+// and reloads them when the files change. The last valid files stay
+// in use when a reload fails.
+type certificateFiles struct {
+	certFile, keyFile, caFile string
+
+	mx          sync.Mutex
+	loaded      bool
+	modTimes    []time.Time
+	certificate *tls.Certificate
+	roots       *x509.CertPool
+	// generation is incremented every time the files are loaded.
+	generation uint64
+}
+
+func newCertificateFiles(certFile, keyFile, caFile string) *certificateFiles {
+	return &certificateFiles{certFile: certFile, keyFile: keyFile, caFile: caFile}
+}
+
+func (c *certificateFiles) files() []string {
+	if len(c.caFile) == 0 {
+		return []string{c.certFile, c.keyFile}
+	}
+	return []string{c.certFile, c.keyFile, c.caFile}
+}
+
+// modified returns the modification times of the files, and if they
+// differ from the loaded ones.
+func (c *certificateFiles) modified() ([]time.Time, bool, error) {
+	res := make([]time.Time, 0, 3)
+	changed := c.loaded == false
+	for i, f := range c.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, false, err
+		}
+		res = append(res, info.ModTime())
+		if changed == false && info.ModTime().Equal(c.modTimes[i]) == false {
+			changed = true
+		}
+	}
+	return res, changed, nil
+}
+
+func (c *certificateFiles) load() error {
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	var roots *x509.CertPool
+	if len(c.caFile) > 0 {
+		data, err := os.ReadFile(c.caFile)
+		if err != nil {
+			return err
+		}
+		roots = x509.NewCertPool()
+		if roots.AppendCertsFromPEM(data) == false {
+			return fmt.Errorf("no certificate found in %s", c.caFile)
+		}
+	}
+	c.certificate = &certificate
+	c.roots = roots
+	return nil
+}
+
+// reload loads the files if they changed since the last load.
+func (c *certificateFiles) reload() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	modTimes, changed, err := c.modified()
+	if err != nil || changed == false {
+		return err
+	}
+	if err := c.load(); err != nil {
+		return err
+	}
+	c.modTimes = modTimes
+	c.loaded = true
+	c.generation++
+	return nil
+}
+
+// refresh reloads the files if needed, and returns the generation of
+// the files in use. Clients compare it with the one of their
+// connections, to establish new ones when the files changed. Reload
+// errors are reported with otel.Handle().
+func (c *certificateFiles) refresh() uint64 {
+	if err := c.reload(); err != nil {
+		otel.Handle(fmt.Errorf("otelog: could not reload client certificate, keeping the previous one: %w", err))
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.generation
+}
+
+// current reloads the files if needed, and returns the certificate
+// and CA in use.
+func (c *certificateFiles) current() (*tls.Certificate, *x509.CertPool) {
+	c.refresh()
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.certificate, c.roots
+}
+
+// tlsConfig returns a tls.Config using the current files on every
+// handshake, to connect to host. It returns an error if the files
+// cannot be loaded initially.
+func (c *certificateFiles) tlsConfig(host string) (*tls.Config, error) {
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	res := &tls.Config{
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			certificate, _ := c.current()
+			return certificate, nil
+		},
+	}
+	if len(c.caFile) == 0 {
+		return res, nil
+	}
+	// The CA may change, so the server certificate is verified
+	// against the current one instead of a static RootCAs.
+	res.InsecureSkipVerify = true
+	res.VerifyConnection = func(state tls.ConnectionState) error {
+		_, roots := c.current()
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("otelog: no server certificate")
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range state.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		// state.ServerName is empty when host is an IP address, so
+		// host is verified explicitly, against the DNS or IP SANs.
+		_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			DNSName:       host,
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		return err
+	}
+	return res, nil
+}
+
+// endpointHost returns the host of a gRPC endpoint, like
+// "localhost:4317" or "dns:///collector:4317".
+func endpointHost(endpoint string) string {
+	if i := strings.LastIndex(endpoint, "/"); i >= 0 {
+		endpoint = endpoint[i+1:]
+	}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return host
+}
@@ -0,0 +1,273 @@
+package otelog
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	collector "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         []byte
+}
+
+func newTestCertificate(t *testing.T, template *x509.Certificate, parent *testCA) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.certificate, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return certificate, key,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func newTestCA(t *testing.T) *testCA {
+	certificate, key, certPEM, _ := newTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil)
+	return &testCA{certificate: certificate, key: key, pem: certPEM}
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestWithClientCertificateFiles(t *testing.T) {
+	ca := newTestCA(t)
+	serverCert, serverKey, _, _ := newTestCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "collector"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+
+	clients := make(chan string, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clients <- r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.certificate)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	modTime := time.Now().Add(-time.Hour)
+	writeClient := func(name string, serial int64) {
+		_, _, certPEM, keyPEM := newTestCertificate(t, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, ca)
+		modTime = modTime.Add(time.Minute)
+		writeFile(t, certFile, certPEM, modTime)
+		writeFile(t, keyFile, keyPEM, modTime)
+	}
+	writeFile(t, caFile, ca.pem, modTime)
+	writeClient("first", 3)
+
+	client, _, err := newLogsClient(newOtelLogExporterOptions(
+		WithHTTPEndpoint(server.URL),
+		WithClientCertificateFiles(certFile, keyFile, caFile)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	export := func(expected string) {
+		if _, err := client.Export(context.Background(), newTestRequest("hello")); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got := <-clients; got != expected {
+			t.Errorf("client certificate = %s, wants %s", got, expected)
+		}
+	}
+
+	export("first")
+	writeClient("second", 4)
+	export("second")
+
+	var reported error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { reported = err }))
+	defer otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {}))
+	modTime = modTime.Add(time.Minute)
+	writeFile(t, certFile, []byte("not a certificate"), modTime)
+	export("second")
+	if reported == nil {
+		t.Errorf("expected the reload error to be reported")
+	}
+}
+
+func TestWithClientCertificateFiles_missing(t *testing.T) {
+	_, _, err := newLogsClient(newOtelLogExporterOptions(
+		WithHTTPEndpoint("https://localhost:4318/v1/logs"),
+		WithClientCertificateFiles("missing.crt", "missing.key", "")))
+	if err == nil {
+		t.Errorf("expected an error for missing certificate files")
+	}
+}
+
+func TestWithClientCertificateFiles_wrongHost(t *testing.T) {
+	ca := newTestCA(t)
+	serverCert, serverKey, _, _ := newTestCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "collector"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.2")},
+		DNSNames:     []string{"collector.example"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// the rejected handshakes are expected.
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	_, _, certPEM, keyPEM := newTestCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+	modTime := time.Now()
+	writeFile(t, certFile, certPEM, modTime)
+	writeFile(t, keyFile, keyPEM, modTime)
+	writeFile(t, caFile, ca.pem, modTime)
+
+	client, _, err := newLogsClient(newOtelLogExporterOptions(
+		WithHTTPEndpoint(server.URL),
+		WithClientCertificateFiles(certFile, keyFile, caFile)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := client.Export(context.Background(), newTestRequest("hello")); err == nil {
+		t.Errorf("expected an error for a certificate of another host")
+	}
+}
+
+type peerLogsServer struct {
+	collector.UnimplementedLogsServiceServer
+	clients chan string
+}
+
+func (s *peerLogsServer) Export(ctx context.Context, req *collector.ExportLogsServiceRequest) (*collector.ExportLogsServiceResponse, error) {
+	p, _ := peer.FromContext(ctx)
+	s.clients <- p.AuthInfo.(credentials.TLSInfo).State.PeerCertificates[0].Subject.CommonName
+	return &collector.ExportLogsServiceResponse{}, nil
+}
+
+func TestWithClientCertificateFiles_grpcReconnects(t *testing.T) {
+	ca := newTestCA(t)
+	serverCert, serverKey, _, _ := newTestCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "collector"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.certificate)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})))
+	logsServer := &peerLogsServer{clients: make(chan string, 1)}
+	collector.RegisterLogsServiceServer(server, logsServer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	modTime := time.Now().Add(-time.Hour)
+	writeClient := func(name string, serial int64) {
+		_, _, certPEM, keyPEM := newTestCertificate(t, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, ca)
+		modTime = modTime.Add(time.Minute)
+		writeFile(t, certFile, certPEM, modTime)
+		writeFile(t, keyFile, keyPEM, modTime)
+	}
+	writeFile(t, caFile, ca.pem, modTime)
+	writeClient("first", 3)
+
+	client, closer, err := newLogsClient(newOtelLogExporterOptions(
+		WithEndpoint(listener.Addr().String()),
+		WithClientCertificateFiles(certFile, keyFile, caFile)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer closer.Close()
+	export := func(expected string) {
+		if _, err := client.Export(context.Background(), newTestRequest("hello")); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got := <-logsServer.clients; got != expected {
+			t.Errorf("client certificate = %s, wants %s", got, expected)
+		}
+	}
+
+	export("first")
+	writeClient("second", 4)
+	export("second")
+}
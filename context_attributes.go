@@ -0,0 +1,64 @@
+package otelog
+
+import (
+	"context"
+
+	"github.com/atuleu/otelog/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+type attributesContextKey struct{}
+
+// ContextWithAttributes returns a copy of ctx carrying attrs, in
+// addition to the attributes already carried by ctx. Attributes
+// replace any carried attribute with the same key.
+//
+// Integrations add these attributes to every record emitted within
+// the returned context, see MergeContextAttributes().
+func ContextWithAttributes(ctx context.Context, attrs ...attribute.KeyValue) context.Context {
+	existing := AttributesFromContext(ctx)
+	merged := make([]attribute.KeyValue, 0, len(existing)+len(attrs))
+	for _, kv := range existing {
+		if containsKey(attrs, kv.Key) == false {
+			merged = append(merged, kv)
+		}
+	}
+	for i, kv := range attrs {
+		// only the last value of a duplicated key is kept.
+		if containsKey(attrs[i+1:], kv.Key) == false {
+			merged = append(merged, kv)
+		}
+	}
+	return context.WithValue(ctx, attributesContextKey{}, merged)
+}
+
+// AttributesFromContext returns the attributes carried by ctx, as
+// set by ContextWithAttributes().
+func AttributesFromContext(ctx context.Context) []attribute.KeyValue {
+	attrs, _ := ctx.Value(attributesContextKey{}).([]attribute.KeyValue)
+	return attrs
+}
+
+func containsKey(attrs []attribute.KeyValue, key attribute.Key) bool {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeContextAttributes adds the attributes carried by ctx to
+// record. The attributes of the record take precedence: a context
+// attribute is only added if record has no attribute with the same
+// key. Within ctx, attributes set by the innermost
+// ContextWithAttributes() call take precedence.
+func MergeContextAttributes(ctx context.Context, record *logs.LogRecord) {
+	for _, kv := range AttributesFromContext(ctx) {
+		if findAttribute(record.Attributes, string(kv.Key)) != nil {
+			continue
+		}
+		record.Attributes = append(record.Attributes, utils.KeyValue(kv))
+	}
+}
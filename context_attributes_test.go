@@ -0,0 +1,44 @@
+package otelog
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestContextWithAttributes(t *testing.T) {
+	ctx := ContextWithAttributes(context.Background(),
+		attribute.String("tenant.id", "acme"),
+		attribute.String("request.id", "r1"))
+	ctx = ContextWithAttributes(ctx,
+		attribute.String("request.id", "r2"),
+		attribute.String("user.id", "u1"),
+		attribute.String("user.id", "u2"))
+
+	expected := map[attribute.Key]string{"tenant.id": "acme", "request.id": "r2", "user.id": "u2"}
+	attrs := AttributesFromContext(ctx)
+	if len(attrs) != len(expected) {
+		t.Errorf("AttributesFromContext() = %v, wants %v", attrs, expected)
+	}
+	for _, kv := range attrs {
+		if kv.Value.AsString() != expected[kv.Key] {
+			t.Errorf("attribute %s = %q, wants %q", kv.Key, kv.Value.AsString(), expected[kv.Key])
+		}
+	}
+
+	record := newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "hello",
+		&common.KeyValue{Key: "user.id", Value: stringValue("explicit")})
+	MergeContextAttributes(ctx, record)
+	if len(record.Attributes) != 3 {
+		t.Errorf("attributes = %v, wants 3 attributes", record.Attributes)
+	}
+	if v := findAttribute(record.Attributes, "user.id"); v.Value.GetStringValue() != "explicit" {
+		t.Errorf("user.id = %v, wants the record attribute to take precedence", v)
+	}
+	if v := findAttribute(record.Attributes, "tenant.id"); v.GetValue().GetStringValue() != "acme" {
+		t.Errorf("tenant.id = %v, wants %q", v, "acme")
+	}
+}
@@ -0,0 +1,283 @@
+package otelog
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+const (
+	// RepeatCountKey is the attribute of a summary record reporting
+	// the number of suppressed duplicates.
+	RepeatCountKey = "log.repeat_count"
+	// FirstSeenKey is the attribute of a summary record reporting
+	// the time of the first occurrence.
+	FirstSeenKey = "log.first_seen"
+	// LastSeenKey is the attribute of a summary record reporting the
+	// time of the last suppressed duplicate.
+	LastSeenKey = "log.last_seen"
+)
+
+type dedupGroup struct {
+	ctx     context.Context
+	timer   *time.Timer
+	summary *logs.LogRecord
+
+	traceID   []byte
+	spanID    []byte
+	flags     uint32
+	firstSeen uint64
+	lastSeen  uint64
+	count     int
+}
+
+type dedupOptions struct {
+	maxGroups int
+}
+
+// DedupOption is an option for NewDedupProcessor().
+type DedupOption interface {
+	apply(opts *dedupOptions)
+}
+
+type dedupOptionFunc func(opts *dedupOptions)
+
+func (f dedupOptionFunc) apply(opts *dedupOptions) {
+	f(opts)
+}
+
+// WithDedupMaxGroups sets the maximal number of distinct records
+// tracked at once. Once reached, new distinct records are forwarded
+// without being deduplicated. Defaults to 10000.
+func WithDedupMaxGroups(n int) DedupOption {
+	return dedupOptionFunc(func(opts *dedupOptions) {
+		opts.maxGroups = n
+	})
+}
+
+func newDedupOptions(options ...DedupOption) dedupOptions {
+	res := dedupOptions{maxGroups: 10000}
+	for _, o := range options {
+		o.apply(&res)
+	}
+	return res
+}
+
+type dedupProcessor struct {
+	chainedProcessor
+	dedupOptions
+
+	window time.Duration
+	seed   maphash.Seed
+
+	mx     sync.Mutex
+	groups map[uint64]*dedupGroup
+}
+
+// NewDedupProcessor creates a LogProcessor that suppresses identical
+// records, with the same body, severity and attributes, within
+// window.
+//
+// The first occurrence of a record is forwarded to next, and starts
+// the window. Duplicates within the window are suppressed. At the
+// end of the window, if any duplicate was suppressed, a summary
+// record is forwarded with the RepeatCountKey, FirstSeenKey and
+// LastSeenKey attributes, and the TraceId and SpanId of the first
+// occurrence.
+func NewDedupProcessor(next LogProcessor, window time.Duration, options ...DedupOption) LogProcessor {
+	return &dedupProcessor{
+		chainedProcessor: chainedProcessor{next},
+		dedupOptions:     newDedupOptions(options...),
+		window:           window,
+		seed:             maphash.MakeSeed(),
+		groups:           make(map[uint64]*dedupGroup),
+	}
+}
+
+// WithDedup adds a LogProcessor that suppresses duplicate records. See
+// NewDedupProcessor().
+func WithDedup(window time.Duration, options ...DedupOption) LogExporterOption {
+	return WithLogProcessor(func(next LogProcessor) LogProcessor {
+		return NewDedupProcessor(next, window, options...)
+	})
+}
+
+func (p *dedupProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	key := p.recordKey(record)
+	seen := record.TimeUnixNano
+	if seen == 0 {
+		seen = uint64(time.Now().UnixNano())
+	}
+
+	p.mx.Lock()
+	group, ok := p.groups[key]
+	if ok == false && len(p.groups) >= p.maxGroups {
+		p.mx.Unlock()
+		return p.next.OnEmit(ctx, record)
+	}
+	if ok == false {
+		group = &dedupGroup{
+			ctx:       detachContext(ctx),
+			traceID:   record.TraceId,
+			spanID:    record.SpanId,
+			flags:     record.Flags,
+			firstSeen: seen,
+		}
+		group.timer = time.AfterFunc(p.window, func() { p.expire(key, group) })
+		p.groups[key] = group
+		p.mx.Unlock()
+		return p.next.OnEmit(ctx, record)
+	}
+
+	group.count += 1
+	group.lastSeen = seen
+	if group.summary == nil {
+		// the duplicate is suppressed, we own it.
+		group.summary = record
+	}
+	p.mx.Unlock()
+	return nil
+}
+
+func (p *dedupProcessor) recordKey(record *logs.LogRecord) uint64 {
+	var h maphash.Hash
+	h.SetSeed(p.seed)
+	h.WriteString(record.SeverityNumber.String())
+	h.WriteByte(0)
+	writeValue(&h, record.Body)
+	writeKeyValues(&h, record.Attributes)
+	return h.Sum64()
+}
+
+// writeValue writes a canonical encoding of v to h, which includes
+// its type, and in which the keys of kvlists are sorted.
+func writeValue(h *maphash.Hash, v *common.AnyValue) {
+	var buffer [8]byte
+	writeUint := func(n uint64) {
+		binary.LittleEndian.PutUint64(buffer[:], n)
+		h.Write(buffer[:])
+	}
+	writeString := func(s string) {
+		writeUint(uint64(len(s)))
+		h.WriteString(s)
+	}
+
+	switch vv := v.GetValue().(type) {
+	case *common.AnyValue_StringValue:
+		h.WriteByte('s')
+		writeString(vv.StringValue)
+	case *common.AnyValue_BoolValue:
+		h.WriteByte('b')
+		if vv.BoolValue == true {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case *common.AnyValue_IntValue:
+		h.WriteByte('i')
+		writeUint(uint64(vv.IntValue))
+	case *common.AnyValue_DoubleValue:
+		h.WriteByte('d')
+		writeUint(math.Float64bits(vv.DoubleValue))
+	case *common.AnyValue_BytesValue:
+		h.WriteByte('y')
+		writeString(string(vv.BytesValue))
+	case *common.AnyValue_ArrayValue:
+		h.WriteByte('a')
+		values := vv.ArrayValue.GetValues()
+		writeUint(uint64(len(values)))
+		for _, e := range values {
+			writeValue(h, e)
+		}
+	case *common.AnyValue_KvlistValue:
+		h.WriteByte('k')
+		writeKeyValues(h, vv.KvlistValue.GetValues())
+	default:
+		h.WriteByte('n')
+	}
+}
+
+// writeKeyValues writes a canonical encoding of kvs, sorted by key,
+// to h.
+func writeKeyValues(h *maphash.Hash, kvs []*common.KeyValue) {
+	sorted := append([]*common.KeyValue(nil), kvs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+	var buffer [8]byte
+	binary.LittleEndian.PutUint64(buffer[:], uint64(len(sorted)))
+	h.Write(buffer[:])
+	for _, kv := range sorted {
+		binary.LittleEndian.PutUint64(buffer[:], uint64(len(kv.Key)))
+		h.Write(buffer[:])
+		h.WriteString(kv.Key)
+		writeValue(h, kv.Value)
+	}
+}
+
+func (p *dedupProcessor) expire(key uint64, group *dedupGroup) {
+	p.mx.Lock()
+	if p.groups[key] != group {
+		p.mx.Unlock()
+		return
+	}
+	delete(p.groups, key)
+	p.mx.Unlock()
+
+	if err := p.emitSummary(group); err != nil {
+		otel.Handle(err)
+	}
+}
+
+func (p *dedupProcessor) emitSummary(group *dedupGroup) error {
+	if group.count == 0 {
+		return nil
+	}
+	summary := group.summary
+	summary.TraceId = group.traceID
+	summary.SpanId = group.spanID
+	summary.Flags = group.flags
+	summary.TimeUnixNano = group.lastSeen
+	summary.Attributes = append(summary.Attributes,
+		&common.KeyValue{Key: RepeatCountKey, Value: intValue(int64(group.count))},
+		&common.KeyValue{Key: FirstSeenKey, Value: stringValue(formatUnixNano(group.firstSeen))},
+		&common.KeyValue{Key: LastSeenKey, Value: stringValue(formatUnixNano(group.lastSeen))},
+	)
+	return p.next.OnEmit(group.ctx, summary)
+}
+
+func formatUnixNano(t uint64) string {
+	return time.Unix(0, int64(t)).UTC().Format(time.RFC3339Nano)
+}
+
+// ForceFlush emits the summary of all current windows, and flushes
+// next.
+func (p *dedupProcessor) ForceFlush(ctx context.Context) error {
+	p.mx.Lock()
+	groups := p.groups
+	p.groups = make(map[uint64]*dedupGroup)
+	p.mx.Unlock()
+
+	for _, group := range groups {
+		group.timer.Stop()
+		if err := p.emitSummary(group); err != nil {
+			otel.Handle(err)
+		}
+	}
+	return p.next.ForceFlush(ctx)
+}
+
+func (p *dedupProcessor) Shutdown(ctx context.Context) error {
+	if err := p.ForceFlush(ctx); err != nil {
+		otel.Handle(err)
+	}
+	return p.next.Shutdown(ctx)
+}
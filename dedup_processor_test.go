@@ -0,0 +1,141 @@
+package otelog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestDedupProcessor(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewDedupProcessor(last, time.Hour)
+
+	emit := func(at int64, body string, traceID byte) {
+		processor.OnEmit(context.Background(), &logs.LogRecord{
+			TimeUnixNano:   uint64(at),
+			SeverityNumber: logs.SeverityNumber_SEVERITY_NUMBER_ERROR,
+			Body:           stringValue(body),
+			TraceId:        []byte{traceID},
+			Attributes: []*common.KeyValue{
+				{Key: "b", Value: stringValue("b")},
+				{Key: "a", Value: intValue(1)},
+			},
+		})
+	}
+
+	emit(1, "connection refused", 1)
+	emit(2, "connection refused", 2)
+	emit(3, "timeout", 3)
+	emit(4, "connection refused", 4)
+
+	if n := len(last.Records()); n != 2 {
+		t.Fatalf("len(records) = %d before flush, wants 2", n)
+	}
+
+	processor.ForceFlush(context.Background())
+
+	records := last.Records()
+	if len(records) != 3 {
+		t.Fatalf("len(records) = %d after flush, wants 3", len(records))
+	}
+	summary := records[2]
+	if body := summary.Body.GetStringValue(); body != "connection refused" {
+		t.Errorf("summary body = %q, wants %q", body, "connection refused")
+	}
+	if summary.TraceId[0] != 1 {
+		t.Errorf("summary TraceId = %x, wants 01", summary.TraceId)
+	}
+	expected := map[string]*common.AnyValue{
+		RepeatCountKey: intValue(2),
+		FirstSeenKey:   stringValue(formatUnixNano(1)),
+		LastSeenKey:    stringValue(formatUnixNano(4)),
+	}
+	for k, v := range expected {
+		kv := findAttribute(summary.Attributes, k)
+		if kv == nil {
+			t.Errorf("missing summary attribute %s", k)
+			continue
+		}
+		if cmp, ok := compareValues(kv.Value, v); ok == false || cmp != 0 {
+			t.Errorf("%s = %v, wants %v", k, kv.Value, v)
+		}
+	}
+	if last.flushed != 1 {
+		t.Errorf("ForceFlush() was not forwarded")
+	}
+}
+
+func TestDedupProcessor_windowExpires(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewDedupProcessor(last, 5*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		processor.OnEmit(context.Background(), &logs.LogRecord{Body: stringValue("a")})
+	}
+	time.Sleep(50 * time.Millisecond)
+	processor.OnEmit(context.Background(), &logs.LogRecord{Body: stringValue("a")})
+
+	records := last.Records()
+	if len(records) != 3 {
+		t.Fatalf("len(records) = %d, wants 3", len(records))
+	}
+	if findAttribute(records[1].Attributes, RepeatCountKey) == nil {
+		t.Errorf("expected the second record to be a summary")
+	}
+	if findAttribute(records[2].Attributes, RepeatCountKey) != nil {
+		t.Errorf("expected the third record to start a new window")
+	}
+}
+
+func TestDedupProcessor_structuredValues(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewDedupProcessor(last, time.Hour)
+
+	kvlist := func(kvs ...*common.KeyValue) *common.AnyValue {
+		return &common.AnyValue{Value: &common.AnyValue_KvlistValue{
+			KvlistValue: &common.KeyValueList{Values: kvs},
+		}}
+	}
+	bodies := []*common.AnyValue{
+		kvlist(&common.KeyValue{Key: "user", Value: stringValue("alice")}),
+		kvlist(&common.KeyValue{Key: "user", Value: stringValue("bob")}),
+		intValue(1),
+		stringValue("1"),
+		nil,
+		stringValue(""),
+	}
+	for _, body := range bodies {
+		processor.OnEmit(context.Background(), &logs.LogRecord{Body: body})
+	}
+
+	if n := len(last.Records()); n != len(bodies) {
+		t.Errorf("len(records) = %d, wants %d", n, len(bodies))
+	}
+
+	processor.OnEmit(context.Background(), &logs.LogRecord{Body: kvlist(
+		&common.KeyValue{Key: "user", Value: stringValue("alice")},
+	)})
+	if n := len(last.Records()); n != len(bodies) {
+		t.Errorf("identical kvlist was not deduplicated")
+	}
+}
+
+func TestDedupProcessor_maxGroups(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewDedupProcessor(last, time.Hour, WithDedupMaxGroups(2))
+
+	for _, body := range []string{"a", "b", "c", "c", "a"} {
+		processor.OnEmit(context.Background(), &logs.LogRecord{Body: stringValue(body)})
+	}
+
+	records := last.Records()
+	if len(records) != 4 {
+		t.Fatalf("len(records) = %d, wants 4", len(records))
+	}
+	if body := records[3].Body.GetStringValue(); body != "c" {
+		t.Errorf("records[3] body = %q, wants %q", body, "c")
+	}
+}
@@ -0,0 +1,195 @@
+package otelog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// detectDefaultResource builds the Resource used when none, or only
+// a partial one, is provided with WithResource(). It is built from:
+//
+//   - OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES environment
+//     variables, which have the precedence over any other detected
+//     attributes.
+//   - the process information. Command line arguments are not
+//     reported as they may contain secrets.
+//   - the host name.
+//   - the go build information (main module path and version, VCS
+//     revision).
+//   - the container ID, found in cgroup files.
+//   - kubernetes downward API environment variables.
+func detectDefaultResource(ctx context.Context) *resource.Resource {
+	detected, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithProcessPID(),
+		resource.WithProcessExecutableName(),
+		resource.WithProcessExecutablePath(),
+		resource.WithProcessOwner(),
+		resource.WithProcessRuntimeName(),
+		resource.WithProcessRuntimeVersion(),
+		resource.WithProcessRuntimeDescription(),
+		resource.WithDetectors(
+			buildInfoDetector{},
+			containerDetector{},
+			kubernetesDetector{lookup: os.LookupEnv},
+		),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		// detection errors are not fatal, we simply keep what was
+		// detected.
+		otel.Handle(err)
+	}
+	return mergeResources(resource.Default(), detected)
+}
+
+// mergeResources merges b over a, attributes of b have the
+// precedence. If their schema URL conflicts, the schema URL of b is
+// kept.
+func mergeResources(a, b *resource.Resource) *resource.Resource {
+	res, err := resource.Merge(a, b)
+	if err == nil {
+		return res
+	}
+	res, err = resource.Merge(resource.NewSchemaless(a.Attributes()...), b)
+	if err != nil {
+		otel.Handle(err)
+		return b
+	}
+	return res
+}
+
+var (
+	goModulePathKey    = attribute.Key("go.module.path")
+	goModuleVersionKey = attribute.Key("go.module.version")
+	vcsRevisionKey     = attribute.Key("vcs.revision")
+	vcsModifiedKey     = attribute.Key("vcs.modified")
+)
+
+// buildInfoDetector detects the go module and VCS information
+// embedded in the executable.
+type buildInfoDetector struct{}
+
+func (d buildInfoDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	info, ok := debug.ReadBuildInfo()
+	if ok == false {
+		return resource.Empty(), nil
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL,
+		buildInfoAttributes(info)...), nil
+}
+
+func buildInfoAttributes(info *debug.BuildInfo) []attribute.KeyValue {
+	var res []attribute.KeyValue
+	if len(info.Main.Path) > 0 {
+		res = append(res, goModulePathKey.String(info.Main.Path))
+	}
+	if len(info.Main.Version) > 0 && info.Main.Version != "(devel)" {
+		res = append(res, goModuleVersionKey.String(info.Main.Version))
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			res = append(res, vcsRevisionKey.String(s.Value))
+		case "vcs.modified":
+			res = append(res, vcsModifiedKey.Bool(s.Value == "true"))
+		}
+	}
+	return res
+}
+
+var (
+	cgroupV1ContainerIDRegexp = regexp.MustCompile(`^.*/(?:.*[-:])?([0-9a-f]{64})(?:\.|\s*$)`)
+	cgroupV2ContainerIDRegexp = regexp.MustCompile(`/containers/([0-9a-f]{64})/`)
+)
+
+// containerDetector detects the container ID from cgroup v1
+// (/proc/self/cgroup) or cgroup v2 (/proc/self/mountinfo) files.
+type containerDetector struct{}
+
+func (d containerDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	id, err := containerIDFromFile("/proc/self/cgroup", cgroupV1ContainerIDRegexp)
+	if err != nil {
+		return nil, err
+	}
+	if len(id) == 0 {
+		id, err = containerIDFromFile("/proc/self/mountinfo", cgroupV2ContainerIDRegexp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(id) == 0 {
+		return resource.Empty(), nil
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ContainerID(id)), nil
+}
+
+func containerIDFromFile(path string, re *regexp.Regexp) (string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return containerIDFromReader(f, re), nil
+}
+
+func containerIDFromReader(r io.Reader, re *regexp.Regexp) string {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		matches := re.FindStringSubmatch(scanner.Text())
+		if len(matches) > 1 {
+			return matches[1]
+		}
+	}
+	return ""
+}
+
+// kubernetesEnvironment maps the environment variables that are
+// expected to be set through the kubernetes downward API to their
+// resource attributes.
+var kubernetesEnvironment = []struct {
+	env string
+	key attribute.Key
+}{
+	{"K8S_POD_NAME", semconv.K8SPodNameKey},
+	{"K8S_POD_UID", semconv.K8SPodUIDKey},
+	{"K8S_NAMESPACE_NAME", semconv.K8SNamespaceNameKey},
+	{"K8S_NODE_NAME", semconv.K8SNodeNameKey},
+	{"K8S_CONTAINER_NAME", semconv.K8SContainerNameKey},
+	{"K8S_DEPLOYMENT_NAME", semconv.K8SDeploymentNameKey},
+}
+
+// kubernetesDetector detects the kubernetes attributes from
+// environment variables set with the downward API.
+type kubernetesDetector struct {
+	lookup func(string) (string, bool)
+}
+
+func (d kubernetesDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	var attrs []attribute.KeyValue
+	for _, e := range kubernetesEnvironment {
+		if v, ok := d.lookup(e.env); ok == true && len(v) > 0 {
+			attrs = append(attrs, e.key.String(v))
+		}
+	}
+	if len(attrs) == 0 {
+		return resource.Empty(), nil
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...), nil
+}
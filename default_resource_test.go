@@ -0,0 +1,115 @@
+package otelog
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+func TestMergeResources_userTakesPrecedence(t *testing.T) {
+	detected := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName("unknown_service"),
+		semconv.HostName("foo"),
+	)
+	user := resource.NewWithAttributes("https://example.com/schema",
+		semconv.ServiceName("bar"),
+	)
+
+	merged := mergeResources(detected, user)
+
+	if merged.SchemaURL() != user.SchemaURL() {
+		t.Errorf("SchemaURL = %q, wants %q", merged.SchemaURL(), user.SchemaURL())
+	}
+
+	expected := map[attribute.Key]string{
+		semconv.ServiceNameKey: "bar",
+		semconv.HostNameKey:    "foo",
+	}
+	for k, v := range expected {
+		value, ok := merged.Set().Value(k)
+		if ok == false {
+			t.Errorf("missing attribute %s", k)
+			continue
+		}
+		if value.AsString() != v {
+			t.Errorf("%s = %q, wants %q", k, value.AsString(), v)
+		}
+	}
+}
+
+func TestDetectDefaultResource_fromEnvironment(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "my-service")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=test")
+
+	res := detectDefaultResource(context.Background())
+
+	expected := map[attribute.Key]string{
+		semconv.ServiceNameKey:           "my-service",
+		semconv.DeploymentEnvironmentKey: "test",
+	}
+	for k, v := range expected {
+		value, ok := res.Set().Value(k)
+		if ok == false || value.AsString() != v {
+			t.Errorf("%s = %q, wants %q", k, value.AsString(), v)
+		}
+	}
+}
+
+func TestKubernetesDetector(t *testing.T) {
+	env := map[string]string{
+		"K8S_POD_NAME":       "my-pod",
+		"K8S_NAMESPACE_NAME": "my-namespace",
+	}
+	detector := kubernetesDetector{lookup: func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}}
+
+	res, err := detector.Detect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if res.Len() != 2 {
+		t.Errorf("len(attributes) = %d, wants 2", res.Len())
+	}
+	if v, _ := res.Set().Value(semconv.K8SPodNameKey); v.AsString() != "my-pod" {
+		t.Errorf("k8s.pod.name = %q, wants %q", v.AsString(), "my-pod")
+	}
+}
+
+func TestContainerIDFromReader(t *testing.T) {
+	id := strings.Repeat("0123456789abcdef", 4)
+	testdata := []struct {
+		Content  string
+		Expected string
+	}{
+		{
+			Content:  "12:pids:/docker/" + id + "\n",
+			Expected: id,
+		},
+		{
+			Content:  "1:name=systemd:/kubepods/besteffort/pod1/cri-containerd-" + id + ".scope\n",
+			Expected: id,
+		},
+		{
+			Content:  "0::/\n",
+			Expected: "",
+		},
+	}
+
+	for _, d := range testdata {
+		if res := containerIDFromReader(strings.NewReader(d.Content), cgroupV1ContainerIDRegexp); res != d.Expected {
+			t.Errorf("containerID(%q) = %q, wants %q", d.Content, res, d.Expected)
+		}
+	}
+
+	mountinfo := "1185 1167 0:1 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw\n"
+	if res := containerIDFromReader(strings.NewReader(mountinfo), cgroupV2ContainerIDRegexp); res != id {
+		t.Errorf("containerID(%q) = %q, wants %q", mountinfo, res, id)
+	}
+}
@@ -0,0 +1,149 @@
+package otelog
+
+import (
+	"context"
+
+	"github.com/atuleu/otelog/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// AttributeConflictPolicy defines what to do when an enriched
+// attribute is already set on a record.
+type AttributeConflictPolicy int
+
+const (
+	// KeepExistingAttribute keeps the record attribute, the enriched
+	// attribute is discarded.
+	KeepExistingAttribute AttributeConflictPolicy = iota
+	// OverwriteAttribute replaces the record attribute value with the
+	// enriched one.
+	OverwriteAttribute
+	// PrefixAttribute keeps the record attribute, and adds the
+	// enriched attribute with a prefixed key. See
+	// WithAttributeConflictPrefix().
+	PrefixAttribute
+)
+
+// ContextAttributesFunc derives attributes from the context a record
+// was emitted within, like a request ID or the authenticated user.
+type ContextAttributesFunc func(ctx context.Context) []attribute.KeyValue
+
+type enrichmentOptions struct {
+	static         []attribute.KeyValue
+	fromContext    []ContextAttributesFunc
+	policy         AttributeConflictPolicy
+	conflictPrefix string
+}
+
+// EnrichmentOption is an option for NewEnrichmentProcessor().
+type EnrichmentOption interface {
+	apply(opts *enrichmentOptions)
+}
+
+type enrichmentOptionFunc func(opts *enrichmentOptions)
+
+func (f enrichmentOptionFunc) apply(opts *enrichmentOptions) {
+	f(opts)
+}
+
+// WithStaticAttributes adds attrs to every record.
+func WithStaticAttributes(attrs ...attribute.KeyValue) EnrichmentOption {
+	return enrichmentOptionFunc(func(opts *enrichmentOptions) {
+		opts.static = append(opts.static, attrs...)
+	})
+}
+
+// WithContextAttributes adds to every record the attributes returned
+// by f for the context the record was emitted within.
+func WithContextAttributes(f ContextAttributesFunc) EnrichmentOption {
+	return enrichmentOptionFunc(func(opts *enrichmentOptions) {
+		opts.fromContext = append(opts.fromContext, f)
+	})
+}
+
+// WithAttributeConflictPolicy sets the policy when an enriched
+// attribute key already exists in the record. Defaults to
+// KeepExistingAttribute.
+func WithAttributeConflictPolicy(policy AttributeConflictPolicy) EnrichmentOption {
+	return enrichmentOptionFunc(func(opts *enrichmentOptions) {
+		opts.policy = policy
+	})
+}
+
+// WithAttributeConflictPrefix sets the prefix used by
+// PrefixAttribute. Defaults to "enriched.".
+func WithAttributeConflictPrefix(prefix string) EnrichmentOption {
+	return enrichmentOptionFunc(func(opts *enrichmentOptions) {
+		opts.conflictPrefix = prefix
+	})
+}
+
+func newEnrichmentOptions(options ...EnrichmentOption) enrichmentOptions {
+	res := enrichmentOptions{
+		policy:         KeepExistingAttribute,
+		conflictPrefix: "enriched.",
+	}
+	for _, o := range options {
+		o.apply(&res)
+	}
+	return res
+}
+
+type enrichmentProcessor struct {
+	chainedProcessor
+	enrichmentOptions
+}
+
+// NewEnrichmentProcessor creates a LogProcessor that adds static and
+// context derived attributes to every record, before forwarding them
+// to next.
+func NewEnrichmentProcessor(next LogProcessor, options ...EnrichmentOption) LogProcessor {
+	return &enrichmentProcessor{
+		chainedProcessor:  chainedProcessor{next},
+		enrichmentOptions: newEnrichmentOptions(options...),
+	}
+}
+
+// WithEnrichment adds a LogProcessor that enriches records with
+// attributes. See NewEnrichmentProcessor().
+func WithEnrichment(options ...EnrichmentOption) LogExporterOption {
+	return WithLogProcessor(func(next LogProcessor) LogProcessor {
+		return NewEnrichmentProcessor(next, options...)
+	})
+}
+
+func (p *enrichmentProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	for _, kv := range p.static {
+		p.enrich(record, kv)
+	}
+	for _, f := range p.fromContext {
+		for _, kv := range f(ctx) {
+			p.enrich(record, kv)
+		}
+	}
+	return p.next.OnEmit(ctx, record)
+}
+
+func (p *enrichmentProcessor) enrich(record *logs.LogRecord, kv attribute.KeyValue) {
+	existing := findAttribute(record.Attributes, string(kv.Key))
+	if existing == nil {
+		record.Attributes = append(record.Attributes, utils.KeyValue(kv))
+		return
+	}
+
+	switch p.policy {
+	case OverwriteAttribute:
+		existing.Value = utils.Value(kv.Value)
+	case PrefixAttribute:
+		prefixed := p.conflictPrefix + string(kv.Key)
+		if existing := findAttribute(record.Attributes, prefixed); existing != nil {
+			existing.Value = utils.Value(kv.Value)
+			return
+		}
+		record.Attributes = append(record.Attributes, utils.KeyValue(attribute.KeyValue{
+			Key:   attribute.Key(prefixed),
+			Value: kv.Value,
+		}))
+	}
+}
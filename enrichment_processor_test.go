@@ -0,0 +1,65 @@
+package otelog
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+type requestIDKey struct{}
+
+func TestEnrichmentProcessor(t *testing.T) {
+	requestID := func(ctx context.Context) []attribute.KeyValue {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok == true {
+			return []attribute.KeyValue{attribute.String("request.id", id)}
+		}
+		return nil
+	}
+
+	testdata := []struct {
+		Policy   AttributeConflictPolicy
+		Expected map[string]string
+	}{
+		{KeepExistingAttribute, map[string]string{
+			"region": "local", "team": "core", "request.id": "abc",
+		}},
+		{OverwriteAttribute, map[string]string{
+			"region": "eu-west", "team": "core", "request.id": "abc",
+		}},
+		{PrefixAttribute, map[string]string{
+			"region": "local", "enriched.region": "eu-west", "team": "core", "request.id": "abc",
+		}},
+	}
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "abc")
+	for _, d := range testdata {
+		last := &recordingProcessor{}
+		processor := NewEnrichmentProcessor(last,
+			WithStaticAttributes(attribute.String("region", "eu-west"), attribute.String("team", "core")),
+			WithContextAttributes(requestID),
+			WithAttributeConflictPolicy(d.Policy),
+		)
+		processor.OnEmit(ctx, &logs.LogRecord{
+			Attributes: []*common.KeyValue{{Key: "region", Value: stringValue("local")}},
+		})
+
+		record := last.Records()[0]
+		if len(record.Attributes) != len(d.Expected) {
+			t.Errorf("policy %d: len(attributes) = %d, wants %d",
+				d.Policy, len(record.Attributes), len(d.Expected))
+		}
+		for k, v := range d.Expected {
+			kv := findAttribute(record.Attributes, k)
+			if kv == nil {
+				t.Errorf("policy %d: missing attribute %s", d.Policy, k)
+				continue
+			}
+			if kv.Value.GetStringValue() != v {
+				t.Errorf("policy %d: %s = %q, wants %q", d.Policy, k, kv.Value.GetStringValue(), v)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package otelog
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// EventNameKey is the attribute holding the name of an event,
+	// as defined by the Open Telemetry event semantic conventions.
+	EventNameKey = "event.name"
+	// EventDomainKey is the attribute holding the domain of an
+	// event, as defined by the Open Telemetry event semantic
+	// conventions.
+	EventDomainKey = "event.domain"
+)
+
+type eventLoggerOptions struct {
+	exporter LogExporter
+	severity Severity
+}
+
+// EventLoggerOption is an option for NewEventLogger().
+type EventLoggerOption interface {
+	apply(opts *eventLoggerOptions)
+}
+
+type eventLoggerOptionFunc func(opts *eventLoggerOptions)
+
+func (f eventLoggerOptionFunc) apply(opts *eventLoggerOptions) {
+	f(opts)
+}
+
+// WithEventExporter routes events to exporter. Defaults to the global
+// LogExporter.
+func WithEventExporter(exporter LogExporter) EventLoggerOption {
+	return eventLoggerOptionFunc(func(opts *eventLoggerOptions) {
+		opts.exporter = exporter
+	})
+}
+
+// WithEventSeverity sets the severity of events emitted with
+// Emit(). Defaults to SeverityInfo.
+func WithEventSeverity(severity Severity) EventLoggerOption {
+	return eventLoggerOptionFunc(func(opts *eventLoggerOptions) {
+		opts.severity = severity
+	})
+}
+
+func newEventLoggerOptions(options ...EventLoggerOption) eventLoggerOptions {
+	res := eventLoggerOptions{
+		severity: SeverityInfo,
+	}
+	for _, o := range options {
+		o.apply(&res)
+	}
+	return res
+}
+
+// EventLogger emits named events, within a domain, as records with
+// the EventNameKey and EventDomainKey attributes.
+type EventLogger struct {
+	logger   *Logger
+	domain   string
+	severity Severity
+}
+
+// NewEventLogger creates an EventLogger for the instrumentation scope
+// identified by name and version, emitting events in domain.
+func NewEventLogger(name, version, domain string, options ...EventLoggerOption) *EventLogger {
+	opts := newEventLoggerOptions(options...)
+	return &EventLogger{
+		logger:   NewLoggerProvider(opts.exporter).Logger(name, version),
+		domain:   domain,
+		severity: opts.severity,
+	}
+}
+
+// Emit emits the event name within ctx, with a structured body built
+// from attrs. By example:
+//
+//	events.Emit(ctx, "order.placed",
+//		attribute.String("order.id", id),
+//		attribute.Float64("order.amount", amount))
+func (l *EventLogger) Emit(ctx context.Context, name string, attrs ...attribute.KeyValue) {
+	l.EmitRecord(ctx, name, Record{severity: l.severity}.WithStructuredBody(attrs...))
+}
+
+// EmitRecord emits record as the event name within ctx.
+func (l *EventLogger) EmitRecord(ctx context.Context, name string, record Record) {
+	l.logger.Emit(ctx, record.WithAttributes(
+		attribute.String(EventNameKey, name),
+		attribute.String(EventDomainKey, l.domain)))
+}
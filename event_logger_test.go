@@ -0,0 +1,49 @@
+package otelog
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestEventLogger(t *testing.T) {
+	exporter := &recordingExporter{}
+	events := NewEventLogger("github.com/example/shop", "v1.0.0", "shop",
+		WithEventExporter(exporter))
+
+	events.Emit(context.Background(), "order.placed",
+		attribute.String("order.id", "A42"),
+		attribute.Float64("order.amount", 12.5))
+	events.EmitRecord(context.Background(), "job.finished",
+		NewRecord(SeverityWarn, "job finished late"))
+
+	records := exporter.Records()
+	if len(records) != 2 {
+		t.Fatalf("len(records) = %d, wants 2", len(records))
+	}
+
+	r := records[0]
+	if name := findAttribute(r.Attributes, EventNameKey); name.GetValue().GetStringValue() != "order.placed" {
+		t.Errorf("event.name = %v, wants %q", name, "order.placed")
+	}
+	if domain := findAttribute(r.Attributes, EventDomainKey); domain.GetValue().GetStringValue() != "shop" {
+		t.Errorf("event.domain = %v, wants %q", domain, "shop")
+	}
+	if r.SeverityNumber != logs.SeverityNumber_SEVERITY_NUMBER_INFO {
+		t.Errorf("severity = %s, wants INFO", r.SeverityNumber)
+	}
+	body := r.Body.GetKvlistValue().GetValues()
+	if len(body) != 2 || body[0].Key != "order.id" || body[1].Value.GetDoubleValue() != 12.5 {
+		t.Errorf("body = %v, wants order.id and order.amount", body)
+	}
+
+	r = records[1]
+	if r.SeverityNumber != logs.SeverityNumber_SEVERITY_NUMBER_WARN || r.Body.GetStringValue() != "job finished late" {
+		t.Errorf("record = %s %v, wants WARN %q", r.SeverityNumber, r.Body, "job finished late")
+	}
+	if name := findAttribute(r.Attributes, EventNameKey); name.GetValue().GetStringValue() != "job.finished" {
+		t.Errorf("event.name = %v, wants %q", name, "job.finished")
+	}
+}
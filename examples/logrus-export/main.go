@@ -6,6 +6,8 @@ import (
 	"flag"
 	"log"
 	"math/rand"
+	"os"
+	"os/signal"
 	"time"
 
 	"github.com/atuleu/otelog"
@@ -13,8 +15,6 @@ import (
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
@@ -36,57 +36,37 @@ func main() {
 
 func execute() error {
 	flag.Parse()
-	if err := setUpLogger(); err != nil {
-		return err
-	}
-
-	setUpLogrusHook()
-
-	for {
-		logSomethingRandom()
-		time.Sleep(*period)
-	}
-}
 
-func setUpLogger() error {
-	resource := resource.NewWithAttributes(semconv.SchemaURL,
-		semconv.ServiceName(*serviceName),
-		semconv.ServiceVersion(*serviceVersion),
-		semconv.ServiceInstanceID(*serviceInstance),
-	)
-	exporter, err := otelog.NewLogExporter(
-		otelog.WithEndpoint(*endpoint),
-		otelog.WithInsecure(),
-		otelog.WithBatchLogProcessor(otelog.WithBatchTimeout(5*time.Second)),
-		otelog.WithResource(resource),
-	)
-	if err != nil {
-		return err
-	}
-	traceExporter, err := otlptrace.New(
-		context.Background(),
-		otlptracegrpc.NewClient(
-			otlptracegrpc.WithInsecure(),
-			otlptracegrpc.WithEndpoint(*endpoint),
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	shutdown, err := otelog.Setup(ctx,
+		otelog.WithLogExporterOptions(
+			otelog.WithEndpoint(*endpoint),
+			otelog.WithInsecure(),
+			otelog.WithBatchLogProcessor(otelog.WithBatchTimeout(5*time.Second)),
+			otelog.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+				semconv.ServiceName(*serviceName),
+				semconv.ServiceVersion(*serviceVersion),
+				semconv.ServiceInstanceID(*serviceInstance),
+			)),
 		),
+		otelog.WithTracerProviderOptions(sdktrace.WithSampler(sdktrace.AlwaysSample())),
+		hooks.WithLogrus(hooks.FromLogrusLevel(logrus.InfoLevel)),
 	)
 	if err != nil {
 		return err
 	}
+	defer shutdown(context.Background())
 
-	otel.SetTracerProvider(sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithBatcher(traceExporter),
-		sdktrace.WithResource(resource),
-	))
-
-	otelog.SetLogExporter(exporter)
-	return nil
-}
-
-func setUpLogrusHook() {
-	hook := hooks.NewLogrusHook(hooks.FromLogrusLevel(logrus.InfoLevel))
-	logrus.AddHook(hook)
+	for {
+		logSomethingRandom()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*period):
+		}
+	}
 }
 
 func logSomethingRandom() {
@@ -0,0 +1,266 @@
+package otelog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	collector "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// newLogsClient creates the client used to send the records, and the
+// resource it owns, if any, to close on shutdown.
+func newLogsClient(opts logExporterOptions) (collector.LogsServiceClient, io.Closer, error) {
+	if err := checkTokenTransport(opts); err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case opts.writer != nil:
+		return &writerLogsClient{w: opts.writer}, nil, nil
+	case len(opts.filePath) > 0:
+		f, err := os.OpenFile(opts.filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &writerLogsClient{w: f}, f, nil
+	case len(opts.httpEndpoint) > 0:
+		client, err := httpClient(opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &httpLogsClient{
+			url:          opts.httpEndpoint,
+			client:       client,
+			tokens:       opts.tokens,
+			certificates: opts.certificates,
+		}, nil, nil
+	case opts.conn != nil:
+		return grpcLogsClient(opts, opts.conn), nil, nil
+	}
+	conn, err := dial(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, redialed := dialedLogsClient(opts, conn)
+	return client, multiCloser{conn, redialed}, nil
+}
+
+// dialedLogsClient creates a client on conn, dialed from opts. conn
+// stays owned by the caller, and the returned closer closes the
+// connections redialed by the client, if any.
+func dialedLogsClient(opts logExporterOptions, conn *grpc.ClientConn) (collector.LogsServiceClient, io.Closer) {
+	var client collector.LogsServiceClient = collector.NewLogsServiceClient(conn)
+	var closer io.Closer = multiCloser{}
+	if opts.certificates != nil {
+		redialing := newRedialingLogsClient(opts, conn)
+		client, closer = redialing, redialing
+	}
+	if opts.tokens != nil {
+		client = invalidatingLogsClient{client: client, tokens: opts.tokens}
+	}
+	return client, closer
+}
+
+// multiCloser closes all its closers.
+type multiCloser []io.Closer
+
+func (c multiCloser) Close() error {
+	var errs []error
+	for _, closer := range c {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// redialingLogsClient redials its connection when the client
+// certificate files change, as gRPC keeps using an established
+// connection, and its certificates, otherwise. The initial connection
+// is left open, and only the redialed ones are closed.
+type redialingLogsClient struct {
+	opts logExporterOptions
+
+	mx         sync.Mutex
+	generation uint64
+	current    *grpcConn
+}
+
+// grpcConn is a connection, with the calls in flight on it.
+type grpcConn struct {
+	conn     *grpc.ClientConn
+	client   collector.LogsServiceClient
+	calls    sync.WaitGroup
+	redialed bool
+}
+
+func (c *grpcConn) close() error {
+	if c.redialed == false {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+func newRedialingLogsClient(opts logExporterOptions, conn *grpc.ClientConn) *redialingLogsClient {
+	return &redialingLogsClient{
+		opts:       opts,
+		generation: opts.certificates.refresh(),
+		current:    &grpcConn{conn: conn, client: collector.NewLogsServiceClient(conn)},
+	}
+}
+
+func (c *redialingLogsClient) Export(ctx context.Context, in *collector.ExportLogsServiceRequest, opts ...grpc.CallOption) (*collector.ExportLogsServiceResponse, error) {
+	c.mx.Lock()
+	if generation := c.opts.certificates.refresh(); generation != c.generation {
+		c.redial(generation)
+	}
+	current := c.current
+	current.calls.Add(1)
+	c.mx.Unlock()
+
+	defer current.calls.Done()
+	return current.client.Export(ctx, in, opts...)
+}
+
+// redial replaces the current connection with a new one. The previous
+// one is closed once its calls are done. It must be called with the
+// lock held.
+func (c *redialingLogsClient) redial(generation uint64) {
+	conn, err := dial(c.opts)
+	if err != nil {
+		otel.Handle(fmt.Errorf("otelog: could not reconnect with the new client certificate: %w", err))
+		return
+	}
+	previous := c.current
+	c.current = &grpcConn{conn: conn, client: collector.NewLogsServiceClient(conn), redialed: true}
+	c.generation = generation
+	go func() {
+		previous.calls.Wait()
+		previous.close()
+	}()
+}
+
+func (c *redialingLogsClient) Close() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.current.close()
+}
+
+// grpcLogsClient creates a client on conn. Tokens are added to each
+// call, as conn was not dialed with them.
+func grpcLogsClient(opts logExporterOptions, conn *grpc.ClientConn) collector.LogsServiceClient {
+	client := collector.NewLogsServiceClient(conn)
+	if opts.tokens == nil {
+		return client
+	}
+	return invalidatingLogsClient{
+		client: authLogsClient{client: client, credentials: opts.tokenCredentials()},
+		tokens: opts.tokens,
+	}
+}
+
+// httpClient returns the http.Client used for OTLP/HTTP.
+func httpClient(opts logExporterOptions) (*http.Client, error) {
+	if opts.certificates == nil {
+		return http.DefaultClient, nil
+	}
+	u, err := url.Parse(opts.httpEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	config, err := opts.certificates.tlsConfig(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	return &http.Client{Transport: transport}, nil
+}
+
+// httpLogsClient sends requests with OTLP/HTTP, encoded in protobuf.
+type httpLogsClient struct {
+	url    string
+	client *http.Client
+	tokens TokenSource
+
+	certificates *certificateFiles
+	// generation is the generation of the certificates last seen.
+	generation atomic.Uint64
+}
+
+func (c *httpLogsClient) Export(ctx context.Context, in *collector.ExportLogsServiceRequest, opts ...grpc.CallOption) (*collector.ExportLogsServiceResponse, error) {
+	if c.certificates != nil {
+		generation := c.certificates.refresh()
+		if c.generation.Swap(generation) != generation {
+			// idle connections would keep using the previous
+			// certificates.
+			c.client.CloseIdleConnections()
+		}
+	}
+	body, err := proto.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	if c.tokens != nil {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && c.tokens != nil {
+		invalidateToken(c.tokens)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("otelog: could not export logs to %s: %s", c.url, resp.Status)
+	}
+
+	res := &collector.ExportLogsServiceResponse{}
+	if err := proto.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// writerLogsClient writes requests as lines of OTLP JSON.
+type writerLogsClient struct {
+	mx sync.Mutex
+	w  io.Writer
+}
+
+func (c *writerLogsClient) Export(ctx context.Context, in *collector.ExportLogsServiceRequest, opts ...grpc.CallOption) (*collector.ExportLogsServiceResponse, error) {
+	data, err := protojson.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if _, err := c.w.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+	return &collector.ExportLogsServiceResponse{}, nil
+}
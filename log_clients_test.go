@@ -0,0 +1,85 @@
+package otelog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	collector "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestRequest(body string) *collector.ExportLogsServiceRequest {
+	return &collector.ExportLogsServiceRequest{
+		ResourceLogs: []*logs.ResourceLogs{{
+			ScopeLogs: []*logs.ScopeLogs{{
+				LogRecords: []*logs.LogRecord{newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, body)},
+			}},
+		}},
+	}
+}
+
+func TestHTTPLogsClient(t *testing.T) {
+	received := make(chan *collector.ExportLogsServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/x-protobuf" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		req := &collector.ExportLogsServiceRequest{}
+		if err := proto.Unmarshal(data, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- req
+		if strings.HasSuffix(r.URL.Path, "/failing") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := &httpLogsClient{url: server.URL + "/v1/logs", client: server.Client()}
+	if _, err := client.Export(context.Background(), newTestRequest("hello")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	req := <-received
+	if body := req.ResourceLogs[0].ScopeLogs[0].LogRecords[0].Body.GetStringValue(); body != "hello" {
+		t.Errorf("received body %q, wants %q", body, "hello")
+	}
+
+	client.url = server.URL + "/failing"
+	if _, err := client.Export(context.Background(), newTestRequest("hello")); err == nil {
+		t.Errorf("expected an error on a non 2xx status")
+	}
+}
+
+func TestWriterLogsClient(t *testing.T) {
+	buffer := bytes.Buffer{}
+	client := &writerLogsClient{w: &buffer}
+	for _, body := range []string{"first", "second"} {
+		if _, err := client.Export(context.Background(), newTestRequest(body)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, wants 2", len(lines))
+	}
+	for i, body := range []string{"first", "second"} {
+		req := &collector.ExportLogsServiceRequest{}
+		if err := protojson.Unmarshal([]byte(lines[i]), req); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got := req.ResourceLogs[0].ScopeLogs[0].LogRecords[0].Body.GetStringValue(); got != body {
+			t.Errorf("line %d body = %q, wants %q", i, got, body)
+		}
+	}
+}
@@ -1,7 +1,12 @@
 package otelog
 
 import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
 	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
 )
 
 // A LogExporter is used to export log for example, to an Open
@@ -10,11 +15,151 @@ type LogExporter interface {
 	Export(log *logs.LogRecord)
 }
 
+// A FlushableLogExporter is a LogExporter that can export records
+// within a context, flush them, and be shut down. All exporters of
+// this package implement it. Functions accepting a LogExporter use
+// these methods when they are available.
+type FlushableLogExporter interface {
+	LogExporter
+	// ExportContext exports a LogRecord emitted within ctx. The
+	// context is passed to the LogProcessor pipeline. Export() is
+	// equivalent to ExportContext() with context.Background().
+	ExportContext(ctx context.Context, log *logs.LogRecord)
+	// ForceFlush exports all pending LogRecord.
+	ForceFlush(ctx context.Context) error
+	// Shutdown flushes all pending LogRecord and releases any
+	// resources held by the LogExporter. Export should not be called
+	// after Shutdown.
+	Shutdown(ctx context.Context) error
+}
+
+// exportContext exports log within ctx, if exporter supports it.
+func exportContext(exporter LogExporter, ctx context.Context, log *logs.LogRecord) {
+	if e, ok := exporter.(FlushableLogExporter); ok == true {
+		e.ExportContext(ctx, log)
+		return
+	}
+	exporter.Export(log)
+}
+
+// forceFlush flushes exporter, if it supports it.
+func forceFlush(exporter LogExporter, ctx context.Context) error {
+	if e, ok := exporter.(FlushableLogExporter); ok == true {
+		return e.ForceFlush(ctx)
+	}
+	return nil
+}
+
+// shutdown shuts exporter down, if it supports it.
+func shutdown(exporter LogExporter, ctx context.Context) error {
+	if e, ok := exporter.(FlushableLogExporter); ok == true {
+		return e.Shutdown(ctx)
+	}
+	return nil
+}
+
 // Creates a Log exporter that exports nothing.
-func NoopLogExporter() LogExporter {
+func NoopLogExporter() FlushableLogExporter {
 	return &noopLogExporter{}
 }
 
 type noopLogExporter struct{}
 
 func (p *noopLogExporter) Export(log *logs.LogRecord) {}
+
+func (p *noopLogExporter) ExportContext(ctx context.Context, log *logs.LogRecord) {}
+
+func (p *noopLogExporter) ForceFlush(ctx context.Context) error { return nil }
+
+func (p *noopLogExporter) Shutdown(ctx context.Context) error { return nil }
+
+// NewMultiLogExporter creates a LogExporter that exports every
+// LogRecord to all exporters. Each exporter receives its own copy of
+// the records.
+func NewMultiLogExporter(exporters ...LogExporter) FlushableLogExporter {
+	return multiLogExporter(exporters)
+}
+
+type multiLogExporter []LogExporter
+
+func (e multiLogExporter) Export(log *logs.LogRecord) {
+	e.ExportContext(context.Background(), log)
+}
+
+func (e multiLogExporter) ExportContext(ctx context.Context, log *logs.LogRecord) {
+	for i, exporter := range e {
+		if i < len(e)-1 {
+			exportContext(exporter, ctx, proto.Clone(log).(*logs.LogRecord))
+		} else {
+			exportContext(exporter, ctx, log)
+		}
+	}
+}
+
+func (e multiLogExporter) ForceFlush(ctx context.Context) error {
+	var errs []error
+	for _, exporter := range e {
+		errs = append(errs, forceFlush(exporter, ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (e multiLogExporter) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, exporter := range e {
+		errs = append(errs, shutdown(exporter, ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// NewProcessingLogExporter creates a LogExporter that processes every
+// LogRecord with a pipeline of LogProcessor built from stages, before
+// exporting it to exporter. It can be used in front of
+// NewMultiLogExporter(), so all exporters share the same processors,
+// and their state, like sampling decisions or rate limits.
+func NewProcessingLogExporter(exporter LogExporter, stages ...LogProcessorStage) FlushableLogExporter {
+	return &processingLogExporter{
+		processor: chainLogProcessors(exporterProcessor{exporter}, stages),
+	}
+}
+
+type processingLogExporter struct {
+	processor LogProcessor
+}
+
+func (e *processingLogExporter) Export(log *logs.LogRecord) {
+	e.ExportContext(context.Background(), log)
+}
+
+func (e *processingLogExporter) ExportContext(ctx context.Context, log *logs.LogRecord) {
+	if err := e.processor.OnEmit(ctx, log); err != nil {
+		otel.Handle(err)
+	}
+}
+
+func (e *processingLogExporter) ForceFlush(ctx context.Context) error {
+	return e.processor.ForceFlush(ctx)
+}
+
+func (e *processingLogExporter) Shutdown(ctx context.Context) error {
+	return e.processor.Shutdown(ctx)
+}
+
+// exporterProcessor is the last LogProcessor of a
+// processingLogExporter, which exports records to an exporter.
+type exporterProcessor struct {
+	exporter LogExporter
+}
+
+func (p exporterProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	exportContext(p.exporter, ctx, record)
+	return nil
+}
+
+func (p exporterProcessor) ForceFlush(ctx context.Context) error {
+	return forceFlush(p.exporter, ctx)
+}
+
+func (p exporterProcessor) Shutdown(ctx context.Context) error {
+	return shutdown(p.exporter, ctx)
+}
@@ -0,0 +1,81 @@
+package otelog
+
+import (
+	"context"
+	"testing"
+
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// exportOnlyExporter implements only LogExporter, like exporters
+// written before FlushableLogExporter.
+type exportOnlyExporter struct {
+	records []*logs.LogRecord
+}
+
+func (e *exportOnlyExporter) Export(record *logs.LogRecord) {
+	e.records = append(e.records, record)
+}
+
+func TestNewMultiLogExporter(t *testing.T) {
+	legacy := &exportOnlyExporter{}
+	flushable := &recordingExporter{}
+	exporter := NewMultiLogExporter(legacy, flushable)
+
+	logger := NewLoggerProvider(exporter).Logger("test", "")
+	logger.Emit(context.Background(), NewRecord(SeverityInfo, "hello"))
+
+	if len(legacy.records) != 1 || len(flushable.Records()) != 1 {
+		t.Fatalf("exported %d and %d records, wants 1 each", len(legacy.records), len(flushable.Records()))
+	}
+	if legacy.records[0] == flushable.Records()[0] {
+		t.Errorf("exporters share the same record")
+	}
+	if scope := InstrumentationScopeFromContext(flushable.contexts[0]); scope.Name != "test" {
+		t.Errorf("scope = %q, wants %q", scope.Name, "test")
+	}
+
+	if err := exporter.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if flushable.flushed != 1 || flushable.shutdown != 1 {
+		t.Errorf("flushed %d and shut down %d times, wants 1", flushable.flushed, flushable.shutdown)
+	}
+}
+
+func TestNewProcessingLogExporter(t *testing.T) {
+	first := &recordingExporter{}
+	second := &recordingExporter{}
+	emitted := 0
+	exporter := NewProcessingLogExporter(NewMultiLogExporter(first, second),
+		func(next LogProcessor) LogProcessor {
+			return NewFilterProcessor(next, LogFilterFunc(func(ctx context.Context, record *logs.LogRecord) bool {
+				emitted++
+				return record.Body.GetStringValue() == "dropped"
+			}))
+		})
+
+	logger := NewLoggerProvider(exporter).Logger("test", "")
+	logger.Emit(context.Background(), NewRecord(SeverityInfo, "dropped"))
+	logger.Emit(context.Background(), NewRecord(SeverityInfo, "kept"))
+
+	if emitted != 2 {
+		t.Errorf("processed %d records, wants 2", emitted)
+	}
+	if len(first.Records()) != 1 || len(second.Records()) != 1 {
+		t.Fatalf("exported %d and %d records, wants 1 each", len(first.Records()), len(second.Records()))
+	}
+	if scope := InstrumentationScopeFromContext(first.contexts[0]); scope.Name != "test" {
+		t.Errorf("scope = %q, wants %q", scope.Name, "test")
+	}
+
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if first.shutdown != 1 || second.shutdown != 1 {
+		t.Errorf("shut down %d and %d times, wants 1", first.shutdown, second.shutdown)
+	}
+}
@@ -0,0 +1,513 @@
+package otelog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.opentelemetry.io/otel/attribute"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// This file implements a small expression language, modeled on the
+// Open Telemetry collector OTTL, to describe conditions on
+// LogRecord. For example:
+//
+//	severity_number >= SEVERITY_NUMBER_WARN and not IsMatch(body, "^GET /health")
+//	attributes["http.route"] == "/health" or instrumentation_scope.name == "noisy"
+//	attributes["user"] != nil
+
+// expressionError is an error in an expression, with its position.
+type expressionError struct {
+	column  int
+	message string
+}
+
+func (e *expressionError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.column, e.message)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenPunctuation
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value string // unquoted value of tokenString
+	pos   int
+}
+
+func (t token) column() int {
+	return t.pos + 1
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+func isIdentifierRune(r rune, first bool) bool {
+	if r == '_' || unicode.IsLetter(r) {
+		return true
+	}
+	return first == false && (r == '.' || unicode.IsDigit(r))
+}
+
+func tokenize(input string) ([]token, error) {
+	var res []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case isIdentifierRune(r, true):
+			for i < len(runes) && isIdentifierRune(runes[i], false) {
+				i++
+			}
+			res = append(res, token{kind: tokenIdentifier, text: string(runes[start:i]), pos: start})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			res = append(res, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case r == '"':
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, &expressionError{start + 1, "unterminated string"}
+			}
+			i++
+			text := string(runes[start:i])
+			value, err := strconv.Unquote(text)
+			if err != nil {
+				return nil, &expressionError{start + 1, fmt.Sprintf("invalid string %s", text)}
+			}
+			res = append(res, token{kind: tokenString, text: text, value: value, pos: start})
+		case strings.ContainsRune("=!<>", r):
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			text := string(runes[start:i])
+			if text == "=" || text == "!" {
+				return nil, &expressionError{start + 1, fmt.Sprintf("unexpected %q", text)}
+			}
+			res = append(res, token{kind: tokenOperator, text: text, pos: start})
+		case strings.ContainsRune("()[],", r):
+			i++
+			res = append(res, token{kind: tokenPunctuation, text: string(r), pos: start})
+		default:
+			return nil, &expressionError{start + 1, fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(res, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// recordPath designates a field of a LogRecord.
+type recordPath struct {
+	field string
+	key   string
+}
+
+const (
+	pathBody           = "body"
+	pathSeverityNumber = "severity_number"
+	pathSeverityText   = "severity_text"
+	pathAttributes     = "attributes"
+	pathScopeName      = "instrumentation_scope.name"
+	pathScopeVersion   = "instrumentation_scope.version"
+)
+
+func (p recordPath) String() string {
+	if p.field == pathAttributes && len(p.key) > 0 {
+		return fmt.Sprintf("attributes[%q]", p.key)
+	}
+	return p.field
+}
+
+// get returns the value of the path for record, or nil if it is not
+// set.
+func (p recordPath) get(ctx context.Context, record *logs.LogRecord) *common.AnyValue {
+	switch p.field {
+	case pathBody:
+		return record.Body
+	case pathSeverityNumber:
+		return intValue(int64(record.SeverityNumber))
+	case pathSeverityText:
+		return stringValue(record.SeverityText)
+	case pathAttributes:
+		if kv := findAttribute(record.Attributes, p.key); kv != nil {
+			return kv.Value
+		}
+	case pathScopeName:
+		return stringValue(InstrumentationScopeFromContext(ctx).Name)
+	case pathScopeVersion:
+		return stringValue(InstrumentationScopeFromContext(ctx).Version)
+	}
+	return nil
+}
+
+func findAttribute(attributes []*common.KeyValue, key string) *common.KeyValue {
+	for _, kv := range attributes {
+		if kv.Key == key {
+			return kv
+		}
+	}
+	return nil
+}
+
+func intValue(v int64) *common.AnyValue {
+	return &common.AnyValue{Value: &common.AnyValue_IntValue{IntValue: v}}
+}
+
+func stringValue(v string) *common.AnyValue {
+	return &common.AnyValue{Value: &common.AnyValue_StringValue{StringValue: v}}
+}
+
+// valueString returns the string representation of v used for
+// pattern matching.
+func valueString(v *common.AnyValue) string {
+	switch vv := v.GetValue().(type) {
+	case *common.AnyValue_StringValue:
+		return vv.StringValue
+	case *common.AnyValue_BoolValue:
+		return strconv.FormatBool(vv.BoolValue)
+	case *common.AnyValue_IntValue:
+		return strconv.FormatInt(vv.IntValue, 10)
+	case *common.AnyValue_DoubleValue:
+		return strconv.FormatFloat(vv.DoubleValue, 'g', -1, 64)
+	case *common.AnyValue_BytesValue:
+		return string(vv.BytesValue)
+	}
+	return ""
+}
+
+// attributeFromValue converts a record attribute value to an
+// attribute.KeyValue. Composite values are converted to strings.
+func attributeFromValue(key string, value *common.AnyValue) attribute.KeyValue {
+	switch v := value.GetValue().(type) {
+	case *common.AnyValue_BoolValue:
+		return attribute.Bool(key, v.BoolValue)
+	case *common.AnyValue_IntValue:
+		return attribute.Int64(key, v.IntValue)
+	case *common.AnyValue_DoubleValue:
+		return attribute.Float64(key, v.DoubleValue)
+	}
+	return attribute.String(key, valueString(value))
+}
+
+// severityName returns the short name of severity, like "INFO" or
+// "WARN2".
+func severityName(severity logs.SeverityNumber) string {
+	return strings.TrimPrefix(severity.String(), "SEVERITY_NUMBER_")
+}
+
+func valueNumber(v *common.AnyValue) (float64, bool) {
+	switch vv := v.GetValue().(type) {
+	case *common.AnyValue_IntValue:
+		return float64(vv.IntValue), true
+	case *common.AnyValue_DoubleValue:
+		return vv.DoubleValue, true
+	}
+	return 0, false
+}
+
+// compareValues compares a and b. It returns false if they cannot be
+// compared.
+func compareValues(a, b *common.AnyValue) (int, bool) {
+	if na, ok := valueNumber(a); ok == true {
+		nb, ok := valueNumber(b)
+		if ok == false {
+			return 0, false
+		}
+		switch {
+		case na < nb:
+			return -1, true
+		case na > nb:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	sa, aok := a.GetValue().(*common.AnyValue_StringValue)
+	sb, bok := b.GetValue().(*common.AnyValue_StringValue)
+	if aok == true && bok == true {
+		return strings.Compare(sa.StringValue, sb.StringValue), true
+	}
+
+	ba, aok := a.GetValue().(*common.AnyValue_BoolValue)
+	bb, bok := b.GetValue().(*common.AnyValue_BoolValue)
+	if aok == true && bok == true && ba.BoolValue == bb.BoolValue {
+		return 0, true
+	}
+	return 0, false
+}
+
+type expressionParser struct {
+	tokens []token
+	pos    int
+}
+
+func newExpressionParser(input string) (*expressionParser, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	return &expressionParser{tokens: tokens}, nil
+}
+
+func (p *expressionParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *expressionParser) errorf(t token, format string, args ...interface{}) error {
+	return &expressionError{t.column(), fmt.Sprintf(format, args...)}
+}
+
+func (p *expressionParser) accept(kind tokenKind, text string) bool {
+	t := p.peek()
+	if t.kind == kind && t.text == text {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *expressionParser) expect(kind tokenKind, text string) error {
+	if p.accept(kind, text) == false {
+		return p.errorf(p.peek(), "expected %q, got %s", text, p.peek())
+	}
+	return nil
+}
+
+func (p *expressionParser) expectEOF() error {
+	if t := p.peek(); t.kind != tokenEOF {
+		return p.errorf(t, "unexpected %s", t)
+	}
+	return nil
+}
+
+// parseCondition parses:
+//
+//	condition := and ( "or" and )*
+//	and       := not ( "and" not )*
+//	not       := "not" not | "(" condition ")" | comparison | function
+func (p *expressionParser) parseCondition() (LogFilter, error) {
+	res, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	filters := []LogFilter{res}
+	for p.accept(tokenIdentifier, "or") {
+		f, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	if len(filters) == 1 {
+		return res, nil
+	}
+	return FilterAny(filters...), nil
+}
+
+func (p *expressionParser) parseAnd() (LogFilter, error) {
+	res, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	filters := []LogFilter{res}
+	for p.accept(tokenIdentifier, "and") {
+		f, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	if len(filters) == 1 {
+		return res, nil
+	}
+	return FilterAll(filters...), nil
+}
+
+func (p *expressionParser) parseNot() (LogFilter, error) {
+	if p.accept(tokenIdentifier, "not") {
+		f, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return FilterNot(f), nil
+	}
+	if p.accept(tokenPunctuation, "(") {
+		f, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(tokenPunctuation, ")")
+	}
+	if t := p.peek(); t.kind == tokenIdentifier && t.text == "IsMatch" {
+		return p.parseIsMatch()
+	}
+	return p.parseComparison()
+}
+
+func (p *expressionParser) parseIsMatch() (LogFilter, error) {
+	p.next()
+	if err := p.expect(tokenPunctuation, "("); err != nil {
+		return nil, err
+	}
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenPunctuation, ","); err != nil {
+		return nil, err
+	}
+	re, err := p.parseRegexp()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenPunctuation, ")"); err != nil {
+		return nil, err
+	}
+	return pathMatches(path, re), nil
+}
+
+func (p *expressionParser) parseRegexp() (*regexp.Regexp, error) {
+	t := p.next()
+	if t.kind != tokenString {
+		return nil, p.errorf(t, "expected a regular expression string, got %s", t)
+	}
+	re, err := regexp.Compile(t.value)
+	if err != nil {
+		return nil, p.errorf(t, "invalid regular expression: %s", err)
+	}
+	return re, nil
+}
+
+func (p *expressionParser) parseComparison() (LogFilter, error) {
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	op := p.next()
+	if op.kind != tokenOperator {
+		return nil, p.errorf(op, "expected a comparison operator, got %s", op)
+	}
+	valueToken := p.peek()
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		switch op.text {
+		case "==":
+			return FilterNot(pathExists(path)), nil
+		case "!=":
+			return pathExists(path), nil
+		default:
+			return nil, p.errorf(op, "operator %q cannot be used with nil", op.text)
+		}
+	}
+
+	if path.field == pathSeverityNumber {
+		if _, ok := value.Value.(*common.AnyValue_IntValue); ok == false {
+			return nil, p.errorf(valueToken,
+				"severity_number must be compared to a SEVERITY_NUMBER_* constant or an integer, got %s",
+				valueToken)
+		}
+	}
+
+	return pathCompare(path, op.text, value), nil
+}
+
+// parsePath parses a path:
+//
+//	path := "body" | "severity_number" | "severity_text" |
+//	        "attributes" "[" string "]" |
+//	        "instrumentation_scope.name" | "instrumentation_scope.version"
+func (p *expressionParser) parsePath() (recordPath, error) {
+	t := p.next()
+	if t.kind != tokenIdentifier {
+		return recordPath{}, p.errorf(t, "expected a path, got %s", t)
+	}
+	switch t.text {
+	case pathBody, pathSeverityNumber, pathSeverityText, pathScopeName, pathScopeVersion:
+		return recordPath{field: t.text}, nil
+	case pathAttributes:
+		if err := p.expect(tokenPunctuation, "["); err != nil {
+			return recordPath{}, err
+		}
+		key := p.next()
+		if key.kind != tokenString {
+			return recordPath{}, p.errorf(key, "expected an attribute key string, got %s", key)
+		}
+		if err := p.expect(tokenPunctuation, "]"); err != nil {
+			return recordPath{}, err
+		}
+		return recordPath{field: pathAttributes, key: key.value}, nil
+	}
+	return recordPath{}, p.errorf(t, "unknown path %s", t)
+}
+
+// parseLiteral parses a literal value. It returns nil for the nil
+// literal.
+func (p *expressionParser) parseLiteral() (*common.AnyValue, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return stringValue(t.value), nil
+	case tokenNumber:
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return intValue(i), nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf(t, "invalid number %s", t)
+		}
+		return &common.AnyValue{Value: &common.AnyValue_DoubleValue{DoubleValue: f}}, nil
+	case tokenIdentifier:
+		switch t.text {
+		case "nil":
+			return nil, nil
+		case "true", "false":
+			return &common.AnyValue{Value: &common.AnyValue_BoolValue{BoolValue: t.text == "true"}}, nil
+		}
+		if v, ok := logs.SeverityNumber_value[t.text]; ok == true {
+			return intValue(int64(v)), nil
+		}
+	}
+	return nil, p.errorf(t, "expected a value, got %s", t)
+}
@@ -0,0 +1,208 @@
+package otelog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+type scopeContextKey struct{}
+
+// InstrumentationScopeFromContext returns the instrumentation.Scope
+// that emitted the LogRecord processed within ctx. It is available to
+// LogProcessor.
+func InstrumentationScopeFromContext(ctx context.Context) instrumentation.Scope {
+	scope, _ := ctx.Value(scopeContextKey{}).(instrumentation.Scope)
+	return scope
+}
+
+func contextWithInstrumentationScope(ctx context.Context, scope instrumentation.Scope) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, scope)
+}
+
+// A LogFilter is a predicate on LogRecord.
+type LogFilter interface {
+	// Match returns true if the record emitted within ctx matches the
+	// filter.
+	Match(ctx context.Context, record *logs.LogRecord) bool
+}
+
+// LogFilterFunc is a function that implements LogFilter.
+type LogFilterFunc func(ctx context.Context, record *logs.LogRecord) bool
+
+func (f LogFilterFunc) Match(ctx context.Context, record *logs.LogRecord) bool {
+	return f(ctx, record)
+}
+
+// FilterSeverity matches records with a severity number in [min;max].
+func FilterSeverity(min, max logs.SeverityNumber) LogFilter {
+	return LogFilterFunc(func(ctx context.Context, record *logs.LogRecord) bool {
+		return record.SeverityNumber >= min && record.SeverityNumber <= max
+	})
+}
+
+// FilterBody matches records with a body matching re. Non-string
+// bodies are matched with their string representation.
+func FilterBody(re *regexp.Regexp) LogFilter {
+	return pathMatches(recordPath{field: pathBody}, re)
+}
+
+// FilterAttributeExists matches records with an attribute key.
+func FilterAttributeExists(key string) LogFilter {
+	return pathExists(recordPath{field: pathAttributes, key: key})
+}
+
+// FilterAttributeEquals matches records with an attribute key
+// which string representation is value.
+func FilterAttributeEquals(key, value string) LogFilter {
+	path := recordPath{field: pathAttributes, key: key}
+	return LogFilterFunc(func(ctx context.Context, record *logs.LogRecord) bool {
+		v := path.get(ctx, record)
+		return v != nil && valueString(v) == value
+	})
+}
+
+// FilterAttributeMatches matches records with an attribute key which
+// string representation matches re.
+func FilterAttributeMatches(key string, re *regexp.Regexp) LogFilter {
+	return pathMatches(recordPath{field: pathAttributes, key: key}, re)
+}
+
+// FilterScopeName matches records emitted by the instrumentation
+// scope name.
+func FilterScopeName(name string) LogFilter {
+	return LogFilterFunc(func(ctx context.Context, record *logs.LogRecord) bool {
+		return InstrumentationScopeFromContext(ctx).Name == name
+	})
+}
+
+// FilterAll matches records that matches all filters.
+func FilterAll(filters ...LogFilter) LogFilter {
+	return LogFilterFunc(func(ctx context.Context, record *logs.LogRecord) bool {
+		for _, f := range filters {
+			if f.Match(ctx, record) == false {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// FilterAny matches records that matches any of the filters.
+func FilterAny(filters ...LogFilter) LogFilter {
+	return LogFilterFunc(func(ctx context.Context, record *logs.LogRecord) bool {
+		for _, f := range filters {
+			if f.Match(ctx, record) == true {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FilterNot matches records that do not match filter.
+func FilterNot(filter LogFilter) LogFilter {
+	return LogFilterFunc(func(ctx context.Context, record *logs.LogRecord) bool {
+		return filter.Match(ctx, record) == false
+	})
+}
+
+func pathExists(path recordPath) LogFilter {
+	return LogFilterFunc(func(ctx context.Context, record *logs.LogRecord) bool {
+		return path.get(ctx, record) != nil
+	})
+}
+
+func pathMatches(path recordPath, re *regexp.Regexp) LogFilter {
+	return LogFilterFunc(func(ctx context.Context, record *logs.LogRecord) bool {
+		v := path.get(ctx, record)
+		return v != nil && re.MatchString(valueString(v))
+	})
+}
+
+func pathCompare(path recordPath, op string, value *common.AnyValue) LogFilter {
+	return LogFilterFunc(func(ctx context.Context, record *logs.LogRecord) bool {
+		v := path.get(ctx, record)
+		if v == nil {
+			return op == "!="
+		}
+		cmp, ok := compareValues(v, value)
+		if ok == false {
+			return op == "!="
+		}
+		switch op {
+		case "==":
+			return cmp == 0
+		case "!=":
+			return cmp != 0
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		case ">=":
+			return cmp >= 0
+		}
+		return false
+	})
+}
+
+// ParseLogFilter parses a LogFilter from a condition string, using a
+// syntax modeled on the Open Telemetry collector OTTL. Conditions
+// compare a path to a value with ==, !=, <, <=, > or >=, or match a
+// path with a regular expression using IsMatch(). They can be
+// combined with and, or, not and parenthesis. Valid paths are body,
+// severity_number, severity_text, attributes["key"],
+// instrumentation_scope.name and instrumentation_scope.version. A
+// path compared to nil tests for its existence. By example:
+//
+//	severity_number < SEVERITY_NUMBER_WARN and IsMatch(body, "^GET /health")
+//	attributes["component"] == "grpc" and not (attributes["error"] != nil)
+func ParseLogFilter(condition string) (LogFilter, error) {
+	p, err := newExpressionParser(condition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", condition, err)
+	}
+	res, err := p.parseCondition()
+	if err == nil {
+		err = p.expectEOF()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", condition, err)
+	}
+	return res, nil
+}
+
+type filterProcessor struct {
+	chainedProcessor
+	drop LogFilter
+}
+
+// NewFilterProcessor creates a LogProcessor that drops any record
+// matching drop, and forwards the other ones to next.
+func NewFilterProcessor(next LogProcessor, drop LogFilter) LogProcessor {
+	return &filterProcessor{
+		chainedProcessor: chainedProcessor{next},
+		drop:             drop,
+	}
+}
+
+func (p *filterProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	if p.drop.Match(ctx, record) == true {
+		return nil
+	}
+	return p.next.OnEmit(ctx, record)
+}
+
+// WithLogFilter adds a LogProcessor that drops any record matching
+// drop. See NewFilterProcessor().
+func WithLogFilter(drop LogFilter) LogExporterOption {
+	return WithLogProcessor(func(next LogProcessor) LogProcessor {
+		return NewFilterProcessor(next, drop)
+	})
+}
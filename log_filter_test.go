@@ -0,0 +1,128 @@
+package otelog
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func newTestRecord(severity logs.SeverityNumber, body string, attrs ...*common.KeyValue) *logs.LogRecord {
+	return &logs.LogRecord{
+		SeverityNumber: severity,
+		Body:           stringValue(body),
+		Attributes:     attrs,
+	}
+}
+
+func TestLogFilters(t *testing.T) {
+	ctx := contextWithInstrumentationScope(context.Background(),
+		instrumentation.Scope{Name: "noisy"})
+	record := newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "GET /health 200",
+		&common.KeyValue{Key: "status", Value: intValue(200)})
+
+	testdata := []struct {
+		Name     string
+		Filter   LogFilter
+		Expected bool
+	}{
+		{"severity", FilterSeverity(logs.SeverityNumber_SEVERITY_NUMBER_TRACE, logs.SeverityNumber_SEVERITY_NUMBER_INFO4), true},
+		{"severity out of range", FilterSeverity(logs.SeverityNumber_SEVERITY_NUMBER_WARN, logs.SeverityNumber_SEVERITY_NUMBER_FATAL4), false},
+		{"body", FilterBody(regexp.MustCompile("^GET /health")), true},
+		{"attribute exists", FilterAttributeExists("status"), true},
+		{"attribute missing", FilterAttributeExists("error"), false},
+		{"attribute equals", FilterAttributeEquals("status", "200"), true},
+		{"attribute matches", FilterAttributeMatches("status", regexp.MustCompile("^5")), false},
+		{"scope", FilterScopeName("noisy"), true},
+		{"all", FilterAll(FilterScopeName("noisy"), FilterAttributeExists("error")), false},
+		{"any", FilterAny(FilterScopeName("noisy"), FilterAttributeExists("error")), true},
+		{"not", FilterNot(FilterScopeName("noisy")), false},
+	}
+
+	for _, d := range testdata {
+		if res := d.Filter.Match(ctx, record); res != d.Expected {
+			t.Errorf("%s: Match() = %v, wants %v", d.Name, res, d.Expected)
+		}
+	}
+}
+
+func TestParseLogFilter(t *testing.T) {
+	ctx := contextWithInstrumentationScope(context.Background(),
+		instrumentation.Scope{Name: "noisy"})
+	record := newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_DEBUG, "GET /health 200",
+		&common.KeyValue{Key: "status", Value: intValue(200)},
+		&common.KeyValue{Key: "component", Value: stringValue("http")})
+
+	testdata := []struct {
+		Condition string
+		Expected  bool
+	}{
+		{`severity_number < SEVERITY_NUMBER_INFO`, true},
+		{`severity_number >= 9`, false},
+		{`IsMatch(body, "^GET /health")`, true},
+		{`body == "GET /health 200"`, true},
+		{`attributes["status"] == 200 and attributes["component"] == "http"`, true},
+		{`attributes["status"] >= 500 or attributes["error"] != nil`, false},
+		{`attributes["error"] == nil`, true},
+		{`not (instrumentation_scope.name == "noisy")`, false},
+		{`not IsMatch(attributes["component"], "^grpc") and severity_number <= SEVERITY_NUMBER_DEBUG4`, true},
+	}
+
+	for _, d := range testdata {
+		filter, err := ParseLogFilter(d.Condition)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %s", d.Condition, err)
+			continue
+		}
+		if res := filter.Match(ctx, record); res != d.Expected {
+			t.Errorf("%s: Match() = %v, wants %v", d.Condition, res, d.Expected)
+		}
+	}
+}
+
+func TestParseLogFilter_errors(t *testing.T) {
+	testdata := []struct {
+		Condition string
+		Expected  string
+	}{
+		{`severity_number >`, `column 18: expected a value, got end of expression`},
+		{`foo == "bar"`, `column 1: unknown path "foo"`},
+		{`IsMatch(body, "(")`, `column 15: invalid regular expression`},
+		{`body == "unterminated`, `column 9: unterminated string`},
+		{`severity_number == "WARN"`, `column 20: severity_number must be compared`},
+		{`(body == "a"`, `column 13: expected ")"`},
+		{`body == "a" body`, `column 13: unexpected "body"`},
+		{`attributes["a"] < nil`, `column 17: operator "<" cannot be used with nil`},
+	}
+
+	for _, d := range testdata {
+		_, err := ParseLogFilter(d.Condition)
+		if err == nil {
+			t.Errorf("expected an error for %s", d.Condition)
+			continue
+		}
+		if strings.Contains(err.Error(), d.Expected) == false {
+			t.Errorf("error for %s = %q, wants it to contain %q", d.Condition, err, d.Expected)
+		}
+	}
+}
+
+func TestFilterProcessor(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewFilterProcessor(last, FilterBody(regexp.MustCompile("health")))
+
+	processor.OnEmit(context.Background(), newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "GET /health"))
+	processor.OnEmit(context.Background(), newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "GET /users"))
+
+	records := last.Records()
+	if len(records) != 1 {
+		t.Fatalf("len(records) = %d, wants 1", len(records))
+	}
+	if body := records[0].Body.GetStringValue(); body != "GET /users" {
+		t.Errorf("body = %q, wants %q", body, "GET /users")
+	}
+}
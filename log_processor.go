@@ -1,32 +1,126 @@
 package otelog
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
-	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
 	logs "go.opentelemetry.io/proto/otlp/logs/v1"
 )
 
-type logBatchCallback func([]*logs.LogRecord)
-
-// LogProcessor process incoming LogRecord and batches them if needed.
+// LogProcessor process LogRecord emitted by a LogExporter, before
+// they are exported.
+//
+// A LogExporter uses a pipeline of LogProcessor. Each of them but the
+// last one, selected with WithSyncer() or WithBatchLogProcessor(),
+// are created with a LogProcessorStage and forwards the records to
+// the next LogProcessor in the pipeline. They may modify, drop or
+// emit new LogRecord.
 type LogProcessor interface {
-	// batch adds the record to the current batch. If the current
-	// batch is ready to be sent, callback with the current batch
-	// content will be called.
-	batch(record *logs.LogRecord, callback logBatchCallback)
+	// OnEmit is called for every emitted LogRecord. The record may
+	// be modified, and the ownership of the record is passed to the
+	// LogProcessor.
+	OnEmit(ctx context.Context, record *logs.LogRecord) error
+	// ForceFlush exports any record held by the LogProcessor and the
+	// next ones in the pipeline.
+	ForceFlush(ctx context.Context) error
+	// Shutdown flushes any record held by the LogProcessor and stops
+	// it and the next ones in the pipeline. OnEmit should not be
+	// called after Shutdown.
+	Shutdown(ctx context.Context) error
+}
+
+// LogProcessorStage creates a LogProcessor that forwards the records
+// to next. See WithLogProcessor().
+type LogProcessorStage func(next LogProcessor) LogProcessor
+
+// chainLogProcessors builds a pipeline where records are processed
+// by the stages in order, and finally by last.
+func chainLogProcessors(last LogProcessor, stages []LogProcessorStage) LogProcessor {
+	res := last
+	for i := len(stages) - 1; i >= 0; i-- {
+		res = stages[i](res)
+	}
+	return res
+}
+
+// chainedProcessor can be embedded by LogProcessor that forwards
+// their records to a next one, and have nothing to flush.
+type chainedProcessor struct {
+	next LogProcessor
+}
+
+func (p chainedProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+func (p chainedProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+// detachedContext keeps the values of a context, but not its
+// cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// detachContext returns a context with the values of ctx, that is
+// never cancelled. It is used by LogProcessor that emits records
+// after the context they were emitted within is done.
+func detachContext(ctx context.Context) context.Context {
+	return detachedContext{ctx}
 }
 
-type syncProcessor struct{}
+// ErrLogProcessorShutdown is returned when a record is emitted to a
+// LogProcessor that was shut down.
+var ErrLogProcessorShutdown = errors.New("otelog: log processor is shut down")
 
-func (b *syncProcessor) batch(record *logs.LogRecord, callback logBatchCallback) {
-	callback([]*logs.LogRecord{record})
+// scopedRecord is a record, with the instrumentation scope it was
+// emitted within.
+type scopedRecord struct {
+	scope  instrumentation.Scope
+	record *logs.LogRecord
+}
+
+func newScopedRecord(ctx context.Context, record *logs.LogRecord) scopedRecord {
+	return scopedRecord{scope: InstrumentationScopeFromContext(ctx), record: record}
+}
+
+type logBatchCallback func(ctx context.Context, batch []scopedRecord) error
+
+type syncProcessor struct {
+	callback logBatchCallback
+}
+
+func newSyncProcessor(callback logBatchCallback) LogProcessor {
+	return &syncProcessor{callback: callback}
+}
+
+func (p *syncProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	return p.callback(ctx, []scopedRecord{newScopedRecord(ctx, record)})
+}
+
+func (p *syncProcessor) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+func (p *syncProcessor) Shutdown(ctx context.Context) error {
+	return nil
 }
 
 type batchProcessorOptions struct {
-	MaxQueueSize int
-	BatchTimeout time.Duration
+	MaxQueueSize  int
+	BatchTimeout  time.Duration
+	MaxExports    int
+	ExportTimeout time.Duration
 }
 
 // BatchLogProcessorOption is an Option for WithBatchLogProcessor()
@@ -58,76 +152,198 @@ func WithMaxQueueSize(size int) BatchLogProcessorOption {
 	return batchQueueSize(size)
 }
 
+type batchMaxExports int
+
+func (n batchMaxExports) apply(opts *batchProcessorOptions) {
+	opts.MaxExports = int(n)
+}
+
+// WithMaxConcurrentExports sets the maximal number of batches exported
+// at the same time. Once reached, up to n more batches wait for an
+// export to finish, and further batches are dropped and reported with
+// otel.Handle(), so emitters never wait for the collector. Defaults to
+// 1.
+func WithMaxConcurrentExports(n int) BatchLogProcessorOption {
+	return batchMaxExports(n)
+}
+
+type batchExportTimeout time.Duration
+
+func (t batchExportTimeout) apply(opts *batchProcessorOptions) {
+	opts.ExportTimeout = time.Duration(t)
+}
+
+// WithExportTimeout sets the maximal duration of the export of a
+// batch. Defaults to 30 seconds.
+func WithExportTimeout(timeout time.Duration) BatchLogProcessorOption {
+	return batchExportTimeout(timeout)
+}
+
 func newBatchProcessorOptions(options ...BatchLogProcessorOption) batchProcessorOptions {
 	res := batchProcessorOptions{
-		MaxQueueSize: 512,
-		BatchTimeout: 1000 * time.Millisecond,
+		MaxQueueSize:  512,
+		BatchTimeout:  1000 * time.Millisecond,
+		MaxExports:    1,
+		ExportTimeout: 30 * time.Second,
 	}
 	for _, o := range options {
 		o.apply(&res)
 	}
+	if res.MaxExports < 1 {
+		res.MaxExports = 1
+	}
 
 	return res
 }
 
 type batchProcessor struct {
-	mx   sync.RWMutex
-	cond *sync.Cond
+	mx sync.Mutex
 
-	timeout time.Duration
-	buffer  []*logs.LogRecord
-	size    atomic.Int32
+	timeout  time.Duration
+	maxSize  int
+	buffer   []scopedRecord
+	timer    *time.Timer
+	shutdown bool
+
+	callback      logBatchCallback
+	exportTimeout time.Duration
+	// exports limits the number of batches exported at the same
+	// time.
+	exports chan struct{}
+	// pending are the batches waiting for an export slot, at most
+	// maxPending.
+	pending    [][]scopedRecord
+	maxPending int
+	inFlight   sync.WaitGroup
 }
 
-func newBatchProcessor(options ...BatchLogProcessorOption) LogProcessor {
+func newBatchProcessor(callback logBatchCallback, options ...BatchLogProcessorOption) LogProcessor {
 	opts := newBatchProcessorOptions(options...)
 
-	res := &batchProcessor{
-		timeout: opts.BatchTimeout,
-		buffer:  make([]*logs.LogRecord, opts.MaxQueueSize),
+	return &batchProcessor{
+		timeout:       opts.BatchTimeout,
+		maxSize:       opts.MaxQueueSize,
+		buffer:        make([]scopedRecord, 0, opts.MaxQueueSize),
+		callback:      callback,
+		exportTimeout: opts.ExportTimeout,
+		exports:       make(chan struct{}, opts.MaxExports),
+		maxPending:    opts.MaxExports,
 	}
-
-	res.cond = sync.NewCond(res.mx.RLocker())
-	return res
 }
 
-func (b *batchProcessor) batch(record *logs.LogRecord, callback logBatchCallback) {
-	b.mx.RLock()
-	defer b.mx.RUnlock()
+func (b *batchProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
 
-	var newSize int
-	for newSize = int(b.size.Add(1)); newSize > len(b.buffer); {
-		b.cond.Wait()
+	if b.shutdown == true {
+		return ErrLogProcessorShutdown
 	}
 
-	b.buffer[newSize-1] = record
+	b.buffer = append(b.buffer, newScopedRecord(ctx, record))
+
+	if len(b.buffer) == 1 {
+		b.timer = time.AfterFunc(b.timeout, b.processTimeout)
+	}
 
-	if newSize == 1 {
-		go func() {
-			time.Sleep(b.timeout)
-			b.process(callback)
-		}()
+	if len(b.buffer) >= b.maxSize {
+		b.sendAsync(b.takeBatch())
 	}
 
-	if newSize == len(b.buffer) {
-		go b.process(callback)
+	return nil
+}
+
+// takeBatch returns the current batch and resets the buffer. It must
+// be called with the lock held.
+func (b *batchProcessor) takeBatch() []scopedRecord {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.buffer) == 0 {
+		return nil
 	}
+	batch := b.buffer
+	b.buffer = make([]scopedRecord, 0, b.maxSize)
+	return batch
 }
 
-func (b *batchProcessor) process(callback logBatchCallback) {
+func (b *batchProcessor) processTimeout() {
 	b.mx.Lock()
 	defer b.mx.Unlock()
+	b.sendAsync(b.takeBatch())
+}
 
-	size := int(b.size.Load())
-	if size == 0 {
+// sendAsync sends batch in the background. It must be called with
+// the lock held, and never blocks: when all exports are in flight,
+// the batch waits in pending, or is dropped if pending is full.
+func (b *batchProcessor) sendAsync(batch []scopedRecord) {
+	if len(batch) == 0 {
 		return
 	}
+	select {
+	case b.exports <- struct{}{}:
+		b.inFlight.Add(1)
+		go b.export(batch)
+	default:
+		if len(b.pending) >= b.maxPending {
+			otel.Handle(fmt.Errorf("otelog: dropped a batch of %d records, as %d exports are in flight", len(batch), cap(b.exports)))
+			return
+		}
+		b.inFlight.Add(1)
+		b.pending = append(b.pending, batch)
+	}
+}
+
+// export exports batch, and then the pending batches, until none are
+// left. It holds an export slot, that it releases when done.
+func (b *batchProcessor) export(batch []scopedRecord) {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), b.exportTimeout)
+		if err := b.callback(ctx, batch); err != nil {
+			otel.Handle(err)
+		}
+		cancel()
+		b.inFlight.Done()
+
+		b.mx.Lock()
+		if len(b.pending) == 0 {
+			<-b.exports
+			b.mx.Unlock()
+			return
+		}
+		batch = b.pending[0]
+		b.pending = b.pending[1:]
+		b.mx.Unlock()
+	}
+}
+
+func (b *batchProcessor) ForceFlush(ctx context.Context) error {
+	b.mx.Lock()
+	batch := b.takeBatch()
+	b.mx.Unlock()
+
+	var err error
+	if len(batch) > 0 {
+		err = b.callback(ctx, batch)
+	}
 
-	defer b.cond.Broadcast()
+	done := make(chan struct{})
+	go func() {
+		b.inFlight.Wait()
+		close(done)
+	}()
 
-	batch := b.buffer[:size]
-	b.buffer = make([]*logs.LogRecord, len(b.buffer))
-	b.size.Store(0)
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	go callback(batch)
+func (b *batchProcessor) Shutdown(ctx context.Context) error {
+	b.mx.Lock()
+	b.shutdown = true
+	b.mx.Unlock()
+	return b.ForceFlush(ctx)
 }
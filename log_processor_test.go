@@ -1,11 +1,14 @@
 package otelog
 
 import (
+	"context"
 	"log"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"go.opentelemetry.io/otel"
 	logs "go.opentelemetry.io/proto/otlp/logs/v1"
 )
 
@@ -19,32 +22,84 @@ func getOrTimeout[T any](ch <-chan T, timeout time.Duration, t *testing.T) (res
 	}
 }
 
+// recordingProcessor is a LogProcessor that records all emitted
+// records, to be used as the last LogProcessor in tests.
+type recordingProcessor struct {
+	mx       sync.Mutex
+	records  []*logs.LogRecord
+	contexts []context.Context
+	flushed  int
+	shutdown int
+}
+
+func (p *recordingProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.records = append(p.records, record)
+	p.contexts = append(p.contexts, ctx)
+	return nil
+}
+
+func (p *recordingProcessor) ForceFlush(ctx context.Context) error {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.flushed += 1
+	return nil
+}
+
+func (p *recordingProcessor) Shutdown(ctx context.Context) error {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.shutdown += 1
+	return nil
+}
+
+func (p *recordingProcessor) Records() []*logs.LogRecord {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return append([]*logs.LogRecord(nil), p.records...)
+}
+
+// recordingExporter is a LogExporter that records all exported
+// records.
+type recordingExporter struct {
+	recordingProcessor
+}
+
+func (e *recordingExporter) Export(record *logs.LogRecord) {
+	e.OnEmit(context.Background(), record)
+}
+
+func (e *recordingExporter) ExportContext(ctx context.Context, record *logs.LogRecord) {
+	e.OnEmit(ctx, record)
+}
+
 func TestBatchLogProcessor_sendsAfterTimeout(t *testing.T) {
-	processor := newBatchProcessor(WithBatchTimeout(5 * time.Millisecond))
 	called := make(chan struct{})
 
 	log.Printf("coucou")
-	processor.batch(nil, func(batch []*logs.LogRecord) {
+	processor := newBatchProcessor(func(ctx context.Context, batch []scopedRecord) error {
 		defer close(called)
 		if len(batch) != 1 {
 			t.Errorf("len(batch) = %d, wants 1", len(batch))
-			return
+			return nil
 		}
-		if batch[0] != nil {
+		if batch[0].record != nil {
 			t.Errorf("expected record to be nil")
 		}
-	})
+		return nil
+	}, WithBatchTimeout(5*time.Millisecond))
+	processor.OnEmit(context.Background(), nil)
 
-	getOrTimeout(called, 10*time.Millisecond, t)
+	getOrTimeout(called, 20*time.Millisecond, t)
 
 }
 
 func TestBatchLogProcessor_sendsAftermaxQueueSize(t *testing.T) {
-	processor := newBatchProcessor(WithMaxQueueSize(10))
 	called := make(chan struct{})
 
 	nbCalls := atomic.Int32{}
-	callback := func(batch []*logs.LogRecord) {
+	callback := func(ctx context.Context, batch []scopedRecord) error {
 		defer close(called)
 
 		calls := nbCalls.Add(1)
@@ -54,19 +109,146 @@ func TestBatchLogProcessor_sendsAftermaxQueueSize(t *testing.T) {
 
 		if len(batch) != 10 {
 			t.Errorf("len(batch) = %d, wants 10", len(batch))
-			return
+			return nil
 		}
 		for i, r := range batch {
-			if r != nil {
+			if r.record != nil {
 				t.Errorf("expected batch[[%d] to be nil", i)
 			}
 		}
+		return nil
 	}
+	processor := newBatchProcessor(callback, WithMaxQueueSize(10))
 
 	for i := 0; i < 10; i++ {
-		processor.batch(nil, callback)
+		processor.OnEmit(context.Background(), nil)
 	}
 
 	getOrTimeout(called, 10*time.Millisecond, t)
 	time.Sleep(10 * time.Millisecond)
 }
+
+func TestBatchLogProcessor_forceFlushAndShutdown(t *testing.T) {
+	var sent atomic.Int32
+	processor := newBatchProcessor(func(ctx context.Context, batch []scopedRecord) error {
+		sent.Add(int32(len(batch)))
+		return nil
+	}, WithBatchTimeout(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		processor.OnEmit(context.Background(), &logs.LogRecord{})
+	}
+
+	if err := processor.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if sent.Load() != 3 {
+		t.Errorf("sent %d records, wants 3", sent.Load())
+	}
+
+	processor.OnEmit(context.Background(), &logs.LogRecord{})
+	if err := processor.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if sent.Load() != 4 {
+		t.Errorf("sent %d records, wants 4", sent.Load())
+	}
+
+	if err := processor.OnEmit(context.Background(), &logs.LogRecord{}); err != ErrLogProcessorShutdown {
+		t.Errorf("OnEmit() after shutdown = %v, wants %v", err, ErrLogProcessorShutdown)
+	}
+}
+
+func TestBatchLogProcessor_boundsExports(t *testing.T) {
+	release := make(chan struct{})
+	var exporting atomic.Int32
+	var exported atomic.Int32
+	processor := newBatchProcessor(func(ctx context.Context, batch []scopedRecord) error {
+		if n := exporting.Add(1); n > 1 {
+			t.Errorf("%d concurrent exports, wants at most 1", n)
+		}
+		defer exporting.Add(-1)
+		<-release
+		exported.Add(1)
+		return nil
+	}, WithMaxQueueSize(1), WithMaxConcurrentExports(1))
+
+	var dropped atomic.Int32
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { dropped.Add(1) }))
+	defer otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {}))
+
+	emitted := make(chan struct{})
+	go func() {
+		defer close(emitted)
+		for i := 0; i < 3; i++ {
+			processor.OnEmit(context.Background(), &logs.LogRecord{})
+		}
+	}()
+	// emitters are not blocked by the stuck export.
+	getOrTimeout(emitted, time.Second, t)
+	if n := dropped.Load(); n != 1 {
+		t.Errorf("dropped %d batches, wants 1", n)
+	}
+
+	close(release)
+	if err := processor.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := exported.Load(); n != 2 {
+		t.Errorf("exported %d batches, wants 2", n)
+	}
+}
+
+func TestBatchLogProcessor_exportTimeout(t *testing.T) {
+	errs := make(chan error, 1)
+	processor := newBatchProcessor(func(ctx context.Context, batch []scopedRecord) error {
+		<-ctx.Done()
+		errs <- ctx.Err()
+		return nil
+	}, WithMaxQueueSize(1), WithExportTimeout(10*time.Millisecond))
+
+	processor.OnEmit(context.Background(), &logs.LogRecord{})
+	select {
+	case err := <-errs:
+		if err != context.DeadlineExceeded {
+			t.Errorf("export ended with %v, wants %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("export was not cancelled")
+	}
+}
+
+type severityTagger struct {
+	chainedProcessor
+	text string
+}
+
+func (p *severityTagger) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	record.SeverityText += p.text
+	return p.next.OnEmit(ctx, record)
+}
+
+func TestChainLogProcessors_order(t *testing.T) {
+	last := &recordingProcessor{}
+	tag := func(text string) LogProcessorStage {
+		return func(next LogProcessor) LogProcessor {
+			return &severityTagger{chainedProcessor{next}, text}
+		}
+	}
+
+	pipeline := chainLogProcessors(last, []LogProcessorStage{tag("a"), tag("b")})
+	pipeline.OnEmit(context.Background(), &logs.LogRecord{})
+	pipeline.ForceFlush(context.Background())
+	pipeline.Shutdown(context.Background())
+
+	records := last.Records()
+	if len(records) != 1 {
+		t.Fatalf("len(records) = %d, wants 1", len(records))
+	}
+	if records[0].SeverityText != "ab" {
+		t.Errorf("SeverityText = %q, wants %q", records[0].SeverityText, "ab")
+	}
+	if last.flushed != 1 || last.shutdown != 1 {
+		t.Errorf("ForceFlush() and Shutdown() were not forwarded")
+	}
+}
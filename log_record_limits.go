@@ -0,0 +1,151 @@
+package otelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+const (
+	// DefaultAttributeCountLimit is the default maximal number of
+	// attributes of a LogRecord.
+	DefaultAttributeCountLimit = 128
+	// DefaultAttributeValueLengthLimit is the default maximal length
+	// of attribute values. It is unlimited.
+	DefaultAttributeValueLengthLimit = 0
+	// DefaultBodyLengthLimit is the default maximal length of a
+	// LogRecord body. It is unlimited.
+	DefaultBodyLengthLimit = 0
+
+	// truncationMarker is appended to any truncated value. It is a
+	// single character so a truncated value never exceeds its limit.
+	truncationMarker = "…"
+)
+
+// LogRecordLimits are the limits applied to any LogRecord when it is
+// exported, after all LogProcessor. A zero or negative limit means no
+// limit, so only the fields set in a literal are limits.
+type LogRecordLimits struct {
+	// AttributeCountLimit is the maximal number of attributes of a
+	// LogRecord. Extra attributes are dropped and reported in the
+	// LogRecord DroppedAttributesCount.
+	AttributeCountLimit int
+	// AttributeValueLengthLimit is the maximal number of characters
+	// of string attribute values. Longer values are truncated, and
+	// ends with a truncation marker.
+	AttributeValueLengthLimit int
+	// BodyLengthLimit is the maximal number of characters of string
+	// in the body. Longer strings are truncated, and ends with a
+	// truncation marker.
+	BodyLengthLimit int
+}
+
+// NewLogRecordLimits returns the default LogRecordLimits. The
+// defaults can be overriden with the OTEL_LOGRECORD_ATTRIBUTE_COUNT_LIMIT,
+// OTEL_LOGRECORD_ATTRIBUTE_VALUE_LENGTH_LIMIT and
+// OTEL_LOGRECORD_BODY_LENGTH_LIMIT environment variables.
+func NewLogRecordLimits() LogRecordLimits {
+	return LogRecordLimits{
+		AttributeCountLimit: intFromEnv("OTEL_LOGRECORD_ATTRIBUTE_COUNT_LIMIT",
+			DefaultAttributeCountLimit),
+		AttributeValueLengthLimit: intFromEnv("OTEL_LOGRECORD_ATTRIBUTE_VALUE_LENGTH_LIMIT",
+			DefaultAttributeValueLengthLimit),
+		BodyLengthLimit: intFromEnv("OTEL_LOGRECORD_BODY_LENGTH_LIMIT",
+			DefaultBodyLengthLimit),
+	}
+}
+
+func intFromEnv(key string, defaultValue int) int {
+	value, ok := os.LookupEnv(key)
+	if ok == false {
+		return defaultValue
+	}
+	res, err := strconv.Atoi(value)
+	if err != nil {
+		otel.Handle(fmt.Errorf("invalid value for %s: %w", key, err))
+		return defaultValue
+	}
+	return res
+}
+
+// apply applies the limits to record.
+func (l LogRecordLimits) apply(record *logs.LogRecord) {
+	if l.AttributeCountLimit > 0 && len(record.Attributes) > l.AttributeCountLimit {
+		record.DroppedAttributesCount += uint32(len(record.Attributes) - l.AttributeCountLimit)
+		record.Attributes = record.Attributes[:l.AttributeCountLimit]
+	}
+
+	if l.AttributeValueLengthLimit > 0 {
+		for _, kv := range record.Attributes {
+			truncateValue(kv.Value, l.AttributeValueLengthLimit)
+		}
+	}
+
+	if l.BodyLengthLimit > 0 {
+		truncateValue(record.Body, l.BodyLengthLimit)
+	}
+}
+
+// truncateValue truncates any string in v to limit characters.
+// limitsProcessor applies limits to every record. It is the last
+// LogProcessor before the batching one, so limits cover the
+// attributes added by processors, and records are bounded while they
+// wait in a batch.
+type limitsProcessor struct {
+	chainedProcessor
+	limits LogRecordLimits
+}
+
+func (p *limitsProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	p.limits.apply(record)
+	return p.next.OnEmit(ctx, record)
+}
+
+func truncateValue(v *common.AnyValue, limit int) {
+	if v == nil {
+		return
+	}
+	switch vv := v.Value.(type) {
+	case *common.AnyValue_StringValue:
+		vv.StringValue = truncateString(vv.StringValue, limit)
+	case *common.AnyValue_BytesValue:
+		if len(vv.BytesValue) > limit {
+			vv.BytesValue = vv.BytesValue[:limit]
+		}
+	case *common.AnyValue_ArrayValue:
+		if vv.ArrayValue == nil {
+			return
+		}
+		for _, e := range vv.ArrayValue.Values {
+			truncateValue(e, limit)
+		}
+	case *common.AnyValue_KvlistValue:
+		if vv.KvlistValue == nil {
+			return
+		}
+		for _, kv := range vv.KvlistValue.Values {
+			truncateValue(kv.Value, limit)
+		}
+	}
+}
+
+func truncateString(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	// keeps limit-1 characters and the marker.
+	kept := 0
+	for i := range s {
+		if kept == limit-1 {
+			return s[:i] + truncationMarker
+		}
+		kept++
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+package otelog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	collector "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestNewLogRecordLimits_fromEnvironment(t *testing.T) {
+	t.Setenv("OTEL_LOGRECORD_ATTRIBUTE_COUNT_LIMIT", "12")
+	t.Setenv("OTEL_LOGRECORD_ATTRIBUTE_VALUE_LENGTH_LIMIT", "invalid")
+	t.Setenv("OTEL_LOGRECORD_BODY_LENGTH_LIMIT", "1024")
+
+	limits := NewLogRecordLimits()
+	if limits.AttributeCountLimit != 12 {
+		t.Errorf("AttributeCountLimit = %d, wants 12", limits.AttributeCountLimit)
+	}
+	if limits.AttributeValueLengthLimit != DefaultAttributeValueLengthLimit {
+		t.Errorf("AttributeValueLengthLimit = %d, wants %d",
+			limits.AttributeValueLengthLimit, DefaultAttributeValueLengthLimit)
+	}
+	if limits.BodyLengthLimit != 1024 {
+		t.Errorf("BodyLengthLimit = %d, wants 1024", limits.BodyLengthLimit)
+	}
+}
+
+func TestLogRecordLimits_apply(t *testing.T) {
+	limits := LogRecordLimits{
+		AttributeCountLimit:       2,
+		AttributeValueLengthLimit: 4,
+		BodyLengthLimit:           6,
+	}
+
+	record := &logs.LogRecord{
+		Body: stringValue("héllo world"),
+		Attributes: []*common.KeyValue{
+			{Key: "a", Value: stringValue("abcdef")},
+			{Key: "b", Value: stringValue("abc")},
+			{Key: "c", Value: stringValue("abcdef")},
+		},
+		DroppedAttributesCount: 1,
+	}
+
+	limits.apply(record)
+
+	if record.DroppedAttributesCount != 2 {
+		t.Errorf("DroppedAttributesCount = %d, wants 2", record.DroppedAttributesCount)
+	}
+	if len(record.Attributes) != 2 {
+		t.Fatalf("len(Attributes) = %d, wants 2", len(record.Attributes))
+	}
+	if v := record.Attributes[0].Value.GetStringValue(); v != "abc…" {
+		t.Errorf("a = %q, wants %q", v, "abc…")
+	}
+	if v := record.Attributes[1].Value.GetStringValue(); v != "abc" {
+		t.Errorf("b = %q, wants %q", v, "abc")
+	}
+	if v := record.Body.GetStringValue(); v != "héllo…" {
+		t.Errorf("body = %q, wants %q", v, "héllo…")
+	}
+}
+
+func TestLogRecordLimits_unlimited(t *testing.T) {
+	for _, limits := range []LogRecordLimits{{-1, -1, -1}, {}} {
+		record := &logs.LogRecord{
+			Body: stringValue("hello world"),
+			Attributes: []*common.KeyValue{
+				{Key: "a", Value: stringValue("abcdef")},
+			},
+		}
+		limits.apply(record)
+		if len(record.Attributes) != 1 || record.DroppedAttributesCount != 0 {
+			t.Errorf("unexpected dropped attributes")
+		}
+		if v := record.Body.GetStringValue(); v != "hello world" {
+			t.Errorf("body = %q, wants %q", v, "hello world")
+		}
+	}
+}
+
+func TestLogRecordLimits_partial(t *testing.T) {
+	limits := LogRecordLimits{AttributeCountLimit: 1}
+	record := &logs.LogRecord{
+		Body: stringValue("hello world"),
+		Attributes: []*common.KeyValue{
+			{Key: "a", Value: stringValue("abcdef")},
+			{Key: "b", Value: stringValue("abcdef")},
+		},
+	}
+	limits.apply(record)
+	if len(record.Attributes) != 1 || record.Attributes[0].Value.GetStringValue() != "abcdef" {
+		t.Errorf("attributes = %v, wants a single untruncated attribute", record.Attributes)
+	}
+	if v := record.Body.GetStringValue(); v != "hello world" {
+		t.Errorf("body = %q, wants %q", v, "hello world")
+	}
+}
+
+func TestOtelExporter_limitsAfterProcessors(t *testing.T) {
+	buffer := bytes.Buffer{}
+	exporter := newOtelExporter(newOtelLogExporterOptions(
+		WithSyncer(),
+		WithLogRecordLimits(LogRecordLimits{AttributeCountLimit: 1}),
+		WithEnrichment(WithStaticAttributes(attribute.String("added", "value")))),
+		&writerLogsClient{w: &buffer})
+
+	exporter.Export(newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "hello",
+		&common.KeyValue{Key: "a", Value: stringValue("b")}))
+
+	req := &collector.ExportLogsServiceRequest{}
+	if err := protojson.Unmarshal(buffer.Bytes(), req); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	record := req.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	if len(record.Attributes) != 1 || record.DroppedAttributesCount != 1 {
+		t.Errorf("attributes = %v, dropped %d, wants 1 attribute and 1 dropped",
+			record.Attributes, record.DroppedAttributesCount)
+	}
+}
+
+func TestOtelExporter_limitsBeforeBatching(t *testing.T) {
+	exporter := newOtelExporter(newOtelLogExporterOptions(
+		WithBatchLogProcessor(WithBatchTimeout(time.Hour)),
+		WithLogRecordLimits(LogRecordLimits{BodyLengthLimit: 4})),
+		&writerLogsClient{w: io.Discard})
+	defer exporter.Shutdown(context.Background())
+
+	record := newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "a very long body")
+	exporter.Export(record)
+	if body := record.Body.GetStringValue(); body != "a v…" {
+		t.Errorf("batched body = %q, wants %q", body, "a v…")
+	}
+}
@@ -0,0 +1,211 @@
+package otelog
+
+import (
+	"context"
+	"time"
+
+	"github.com/atuleu/otelog/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/trace"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Severity is the severity of a Record.
+type Severity int32
+
+// Severity values, as defined by the Open Telemetry log data model.
+const (
+	SeverityUndefined Severity = iota
+	SeverityTrace
+	SeverityTrace2
+	SeverityTrace3
+	SeverityTrace4
+	SeverityDebug
+	SeverityDebug2
+	SeverityDebug3
+	SeverityDebug4
+	SeverityInfo
+	SeverityInfo2
+	SeverityInfo3
+	SeverityInfo4
+	SeverityWarn
+	SeverityWarn2
+	SeverityWarn3
+	SeverityWarn4
+	SeverityError
+	SeverityError2
+	SeverityError3
+	SeverityError4
+	SeverityFatal
+	SeverityFatal2
+	SeverityFatal3
+	SeverityFatal4
+)
+
+// String returns the short name of the severity, like "INFO" or
+// "WARN2".
+func (s Severity) String() string {
+	return severityName(logs.SeverityNumber(s))
+}
+
+// Record is a log record emitted with a Logger. Records are built by
+// chaining calls, starting with NewRecord():
+//
+//	otelog.NewRecord(otelog.SeverityInfo, "request done").
+//		WithAttributes(attribute.Int("http.status_code", 200))
+type Record struct {
+	timestamp         time.Time
+	observedTimestamp time.Time
+	severity          Severity
+	severityText      string
+	body              *common.AnyValue
+	attributes        []attribute.KeyValue
+}
+
+// NewRecord creates a Record with severity and a string body.
+func NewRecord(severity Severity, body string) Record {
+	return Record{severity: severity, body: stringValue(body)}
+}
+
+// WithTimestamp sets the time the event occurred. It is left unset by
+// default.
+func (r Record) WithTimestamp(t time.Time) Record {
+	r.timestamp = t
+	return r
+}
+
+// WithObservedTimestamp sets the time the event was observed.
+// Defaults to the time the record is emitted.
+func (r Record) WithObservedTimestamp(t time.Time) Record {
+	r.observedTimestamp = t
+	return r
+}
+
+// WithSeverity sets the severity of the record.
+func (r Record) WithSeverity(severity Severity) Record {
+	r.severity = severity
+	return r
+}
+
+// WithSeverityText sets the severity text of the record, as known by
+// its source. Defaults to the short name of the severity.
+func (r Record) WithSeverityText(text string) Record {
+	r.severityText = text
+	return r
+}
+
+// WithBody sets the body of the record.
+func (r Record) WithBody(body string) Record {
+	r.body = stringValue(body)
+	return r
+}
+
+// WithStructuredBody sets the body of the record to a map built from
+// attrs.
+func (r Record) WithStructuredBody(attrs ...attribute.KeyValue) Record {
+	r.body = &common.AnyValue{
+		Value: &common.AnyValue_KvlistValue{
+			KvlistValue: &common.KeyValueList{Values: utils.KeyValues(attrs)},
+		},
+	}
+	return r
+}
+
+// WithAttributes adds attrs to the attributes of the record.
+func (r Record) WithAttributes(attrs ...attribute.KeyValue) Record {
+	r.attributes = append(r.attributes[:len(r.attributes):len(r.attributes)], attrs...)
+	return r
+}
+
+// logRecord converts r to a LogRecord emitted within ctx.
+func (r Record) logRecord(ctx context.Context) *logs.LogRecord {
+	res := &logs.LogRecord{
+		SeverityNumber: logs.SeverityNumber(r.severity),
+		SeverityText:   r.severityText,
+		Attributes:     utils.KeyValues(r.attributes),
+	}
+	if r.body != nil {
+		// processors may modify the body, which is shared by copies of r.
+		res.Body = proto.Clone(r.body).(*common.AnyValue)
+	}
+	if len(res.SeverityText) == 0 {
+		res.SeverityText = r.severity.String()
+	}
+	if r.timestamp.IsZero() == false {
+		res.TimeUnixNano = uint64(r.timestamp.UnixNano())
+	}
+	observed := r.observedTimestamp
+	if observed.IsZero() == true {
+		observed = time.Now()
+	}
+	res.ObservedTimeUnixNano = uint64(observed.UnixNano())
+
+	spanContext := trace.SpanContextFromContext(ctx)
+	if spanContext.IsValid() == true {
+		traceID, spanID := spanContext.TraceID(), spanContext.SpanID()
+		res.TraceId = traceID[:]
+		res.SpanId = spanID[:]
+		res.Flags = uint32(spanContext.TraceFlags())
+	}
+	return res
+}
+
+// LoggerProvider creates Logger that emit records to a LogExporter.
+type LoggerProvider struct {
+	exporter LogExporter
+}
+
+// NewLoggerProvider creates a LoggerProvider emitting records to
+// exporter, or to the global LogExporter if exporter is nil.
+func NewLoggerProvider(exporter LogExporter) *LoggerProvider {
+	return &LoggerProvider{exporter: exporter}
+}
+
+var globalLoggerProvider = NewLoggerProvider(nil)
+
+// GetLoggerProvider returns a LoggerProvider emitting records to the
+// global LogExporter, as set by SetLogExporter().
+func GetLoggerProvider() *LoggerProvider {
+	return globalLoggerProvider
+}
+
+func (p *LoggerProvider) getExporter() LogExporter {
+	if p.exporter != nil {
+		return p.exporter
+	}
+	return GetLogExporter()
+}
+
+// Logger returns a Logger for the instrumentation scope identified
+// by name and version, typically the package path of the
+// instrumented code and its version.
+func (p *LoggerProvider) Logger(name, version string) *Logger {
+	return &Logger{
+		provider: p,
+		scope:    instrumentation.Scope{Name: name, Version: version},
+	}
+}
+
+// Logger emits Record within an instrumentation scope. It is safe to
+// use concurrently.
+type Logger struct {
+	provider *LoggerProvider
+	scope    instrumentation.Scope
+}
+
+// Emit emits record within ctx. If ctx contains a valid
+// trace.SpanContext, the record is linked with the span. The
+// attributes carried by ctx are added to the record, see
+// MergeContextAttributes().
+func (l *Logger) Emit(ctx context.Context, record Record) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logRecord := record.logRecord(ctx)
+	MergeContextAttributes(ctx, logRecord)
+	exportContext(l.provider.getExporter(),
+		contextWithInstrumentationScope(ctx, l.scope), logRecord)
+}
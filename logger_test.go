@@ -0,0 +1,91 @@
+package otelog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/trace"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestLogger_Emit(t *testing.T) {
+	exporter := &recordingExporter{}
+	logger := NewLoggerProvider(exporter).Logger("github.com/example/app", "v1.2.3")
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	now := time.Unix(1700000000, 0)
+	base := NewRecord(SeverityInfo, "request done").
+		WithAttributes(attribute.String("http.method", "GET"))
+	logger.Emit(ctx, base.WithTimestamp(now).WithAttributes(attribute.Int("http.status_code", 200)))
+	logger.Emit(context.Background(), base.WithSeverity(SeverityWarn2).WithBody("slow request"))
+
+	records := exporter.Records()
+	if len(records) != 2 {
+		t.Fatalf("len(records) = %d, wants 2", len(records))
+	}
+
+	r := records[0]
+	if r.SeverityNumber != logs.SeverityNumber_SEVERITY_NUMBER_INFO || r.SeverityText != "INFO" {
+		t.Errorf("severity = %s %q, wants INFO", r.SeverityNumber, r.SeverityText)
+	}
+	if r.TimeUnixNano != uint64(now.UnixNano()) {
+		t.Errorf("time = %d, wants %d", r.TimeUnixNano, now.UnixNano())
+	}
+	if r.ObservedTimeUnixNano == 0 {
+		t.Errorf("observed time is not set")
+	}
+	if len(r.Attributes) != 2 {
+		t.Errorf("attributes = %v, wants http.method and http.status_code", r.Attributes)
+	}
+	traceID := spanContext.TraceID()
+	if string(r.TraceId) != string(traceID[:]) || r.Flags != 1 {
+		t.Errorf("record is not linked to the span")
+	}
+	expected := instrumentation.Scope{Name: "github.com/example/app", Version: "v1.2.3"}
+	if scope := InstrumentationScopeFromContext(exporter.contexts[0]); scope != expected {
+		t.Errorf("scope = %v, wants %v", scope, expected)
+	}
+
+	r = records[1]
+	if r.SeverityText != "WARN2" || r.Body.GetStringValue() != "slow request" {
+		t.Errorf("record = %s %q, wants WARN2 %q", r.SeverityText, r.Body.GetStringValue(), "slow request")
+	}
+	if len(r.Attributes) != 1 || r.TimeUnixNano != 0 || len(r.TraceId) != 0 {
+		t.Errorf("record was modified by a derived record: %v", r)
+	}
+}
+
+func TestOtelExporter_groupByScope(t *testing.T) {
+	exporterScope := instrumentation.Scope{Name: "otelog"}
+	e := &otelExporter{
+		instrumentationScope: exporterScope,
+		scope:                buildScope(newOtelLogExporterOptions(WithScope(exporterScope))),
+	}
+	other := instrumentation.Scope{Name: "app", Version: "v1", SchemaURL: "https://example.com"}
+	batch := []scopedRecord{
+		{scope: exporterScope, record: &logs.LogRecord{}},
+		{scope: other, record: &logs.LogRecord{}},
+		{scope: exporterScope, record: &logs.LogRecord{}},
+	}
+
+	scopeLogs := e.groupByScope(batch)
+	if len(scopeLogs) != 2 {
+		t.Fatalf("len(scopeLogs) = %d, wants 2", len(scopeLogs))
+	}
+	if scopeLogs[0].Scope != e.scope || len(scopeLogs[0].LogRecords) != 2 {
+		t.Errorf("scopeLogs[0] = %v, wants the exporter scope with 2 records", scopeLogs[0])
+	}
+	if scopeLogs[1].Scope.Name != "app" || scopeLogs[1].Scope.Version != "v1" ||
+		scopeLogs[1].SchemaUrl != "https://example.com" || len(scopeLogs[1].LogRecords) != 1 {
+		t.Errorf("scopeLogs[1] = %v, wants the app scope with 1 record", scopeLogs[1])
+	}
+}
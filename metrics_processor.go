@@ -0,0 +1,216 @@
+package otelog
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+const (
+	// MetricSeverityKey is the metric attribute reporting the
+	// severity of records, like "INFO" or "WARN2".
+	MetricSeverityKey = "log.severity"
+	// MetricScopeKey is the metric attribute reporting the
+	// instrumentation scope name of records.
+	MetricScopeKey = "otel.scope.name"
+)
+
+// LogMetricKind is the kind of instrument updated by a LogMetric.
+type LogMetricKind int
+
+const (
+	// LogMetricCounter counts records, or sums the value of an
+	// attribute.
+	LogMetricCounter LogMetricKind = iota
+	// LogMetricHistogram records the distribution of the value of an
+	// attribute.
+	LogMetricHistogram
+)
+
+// LogMetric describes a metric derived from records.
+type LogMetric struct {
+	// Name, Description and Unit of the instrument.
+	Name        string
+	Description string
+	Unit        string
+
+	Kind LogMetricKind
+	// Value is the key of the numeric attribute recorded. It is
+	// required for histograms. Counters without a Value count
+	// records. Records without a numeric Value are ignored.
+	Value string
+	// Condition selects the records to measure. All records are
+	// measured if nil.
+	Condition LogFilter
+
+	// Severity adds the MetricSeverityKey attribute to measurements.
+	Severity bool
+	// Scope adds the MetricScopeKey attribute to measurements.
+	Scope bool
+	// Attributes are the keys of record attributes added to
+	// measurements.
+	Attributes []string
+}
+
+type metricsOptions struct {
+	meterProvider metric.MeterProvider
+}
+
+// MetricsOption is an option for NewMetricsProcessor().
+type MetricsOption interface {
+	apply(opts *metricsOptions)
+}
+
+type metricsOptionFunc func(opts *metricsOptions)
+
+func (f metricsOptionFunc) apply(opts *metricsOptions) {
+	f(opts)
+}
+
+// WithMetricsMeterProvider sets the MeterProvider used to create the
+// instruments. Defaults to the global MeterProvider.
+func WithMetricsMeterProvider(mp metric.MeterProvider) MetricsOption {
+	return metricsOptionFunc(func(opts *metricsOptions) {
+		opts.meterProvider = mp
+	})
+}
+
+func newMetricsOptions(options ...MetricsOption) metricsOptions {
+	res := metricsOptions{}
+	for _, o := range options {
+		o.apply(&res)
+	}
+	if res.meterProvider == nil {
+		res.meterProvider = otel.GetMeterProvider()
+	}
+	return res
+}
+
+// logMetricInstrument records the measurements of a LogMetric.
+type logMetricInstrument struct {
+	LogMetric
+	record func(ctx context.Context, value float64, options ...metric.RecordOption)
+	count  func(ctx context.Context, value int64, options ...metric.AddOption)
+	sum    func(ctx context.Context, value float64, options ...metric.AddOption)
+}
+
+type metricsProcessor struct {
+	chainedProcessor
+	instruments []logMetricInstrument
+}
+
+// NewMetricsProcessor creates a LogProcessor that updates the
+// instruments described by metrics for every record, before
+// forwarding them to next. Invalid metrics are reported with
+// otel.Handle() and ignored.
+func NewMetricsProcessor(next LogProcessor, metrics []LogMetric, options ...MetricsOption) LogProcessor {
+	opts := newMetricsOptions(options...)
+	meter := opts.meterProvider.Meter("github.com/atuleu/otelog")
+	res := &metricsProcessor{chainedProcessor: chainedProcessor{next}}
+	for _, m := range metrics {
+		instrument, err := newLogMetricInstrument(meter, m)
+		if err != nil {
+			otel.Handle(fmt.Errorf("invalid log metric %q: %w", m.Name, err))
+			continue
+		}
+		res.instruments = append(res.instruments, instrument)
+	}
+	return res
+}
+
+// WithLogMetrics adds a LogProcessor that derives metrics from
+// records. See NewMetricsProcessor(). The processor is added at the
+// beginning of the pipeline, so records dropped by other
+// LogProcessor are measured.
+func WithLogMetrics(metrics []LogMetric, options ...MetricsOption) LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		stage := func(next LogProcessor) LogProcessor {
+			return NewMetricsProcessor(next, metrics, options...)
+		}
+		opts.stages = append([]LogProcessorStage{stage}, opts.stages...)
+	})
+}
+
+func newLogMetricInstrument(meter metric.Meter, m LogMetric) (logMetricInstrument, error) {
+	res := logMetricInstrument{LogMetric: m}
+	switch {
+	case m.Kind == LogMetricHistogram:
+		if len(m.Value) == 0 {
+			return res, fmt.Errorf("histograms require a Value attribute")
+		}
+		h, err := meter.Float64Histogram(m.Name,
+			metric.WithDescription(m.Description), metric.WithUnit(m.Unit))
+		if err != nil {
+			return res, err
+		}
+		res.record = h.Record
+	case m.Kind == LogMetricCounter && len(m.Value) == 0:
+		c, err := meter.Int64Counter(m.Name,
+			metric.WithDescription(m.Description), metric.WithUnit(m.Unit))
+		if err != nil {
+			return res, err
+		}
+		res.count = c.Add
+	case m.Kind == LogMetricCounter:
+		c, err := meter.Float64Counter(m.Name,
+			metric.WithDescription(m.Description), metric.WithUnit(m.Unit))
+		if err != nil {
+			return res, err
+		}
+		res.sum = c.Add
+	default:
+		return res, fmt.Errorf("unknown kind %d", m.Kind)
+	}
+	return res, nil
+}
+
+func (i logMetricInstrument) measure(ctx context.Context, record *logs.LogRecord) {
+	if i.Condition != nil && i.Condition.Match(ctx, record) == false {
+		return
+	}
+
+	var value float64
+	if len(i.Value) > 0 {
+		kv := findAttribute(record.Attributes, i.Value)
+		v, ok := valueNumber(kv.GetValue())
+		if ok == false {
+			return
+		}
+		value = v
+	}
+
+	attributes := make([]attribute.KeyValue, 0, len(i.Attributes)+2)
+	if i.Severity == true {
+		attributes = append(attributes,
+			attribute.String(MetricSeverityKey, severityName(record.SeverityNumber)))
+	}
+	if i.Scope == true {
+		attributes = append(attributes,
+			attribute.String(MetricScopeKey, InstrumentationScopeFromContext(ctx).Name))
+	}
+	for _, k := range i.Attributes {
+		if kv := findAttribute(record.Attributes, k); kv != nil {
+			attributes = append(attributes, attributeFromValue(k, kv.Value))
+		}
+	}
+
+	switch {
+	case i.record != nil:
+		i.record(ctx, value, metric.WithAttributes(attributes...))
+	case i.count != nil:
+		i.count(ctx, 1, metric.WithAttributes(attributes...))
+	case value >= 0:
+		i.sum(ctx, value, metric.WithAttributes(attributes...))
+	}
+}
+
+func (p *metricsProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	for _, i := range p.instruments {
+		i.measure(ctx, record)
+	}
+	return p.next.OnEmit(ctx, record)
+}
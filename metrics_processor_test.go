@@ -0,0 +1,96 @@
+package otelog
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestMetricsProcessor(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	done, err := ParseLogFilter(`body == "request done"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	last := &recordingProcessor{}
+	processor := NewMetricsProcessor(last, []LogMetric{
+		{Name: "log.records", Severity: true},
+		{Name: "request.duration", Unit: "ms", Kind: LogMetricHistogram,
+			Value: "duration_ms", Condition: done, Attributes: []string{"route"}},
+	}, WithMetricsMeterProvider(mp))
+
+	ctx := context.Background()
+	info := logs.SeverityNumber_SEVERITY_NUMBER_INFO
+	processor.OnEmit(ctx, newTestRecord(info, "request done",
+		&common.KeyValue{Key: "duration_ms", Value: intValue(12)},
+		&common.KeyValue{Key: "route", Value: stringValue("/users")}))
+	processor.OnEmit(ctx, newTestRecord(info, "request done",
+		&common.KeyValue{Key: "duration_ms", Value: stringValue("unknown")}))
+	processor.OnEmit(ctx, newTestRecord(info, "starting"))
+	processor.OnEmit(ctx, newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_ERROR, "failed"))
+
+	if n := len(last.Records()); n != 4 {
+		t.Errorf("len(records) = %d, wants 4", n)
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	counts, ok := metrics["log.records"].(metricdata.Sum[int64])
+	if ok == false {
+		t.Fatalf("log.records = %T, wants a Sum[int64]", metrics["log.records"])
+	}
+	expected := map[string]int64{"INFO": 3, "ERROR": 1}
+	if len(counts.DataPoints) != len(expected) {
+		t.Errorf("len(log.records) = %d, wants %d", len(counts.DataPoints), len(expected))
+	}
+	for _, dp := range counts.DataPoints {
+		severity, _ := dp.Attributes.Value(attribute.Key(MetricSeverityKey))
+		if dp.Value != expected[severity.AsString()] {
+			t.Errorf("log.records{%s} = %d, wants %d", severity.AsString(), dp.Value, expected[severity.AsString()])
+		}
+	}
+
+	durations, ok := metrics["request.duration"].(metricdata.Histogram[float64])
+	if ok == false {
+		t.Fatalf("request.duration = %T, wants a Histogram[float64]", metrics["request.duration"])
+	}
+	if len(durations.DataPoints) != 1 {
+		t.Fatalf("len(request.duration) = %d, wants 1", len(durations.DataPoints))
+	}
+	dp := durations.DataPoints[0]
+	if dp.Count != 1 || dp.Sum != 12 {
+		t.Errorf("request.duration count = %d sum = %g, wants 1 and 12", dp.Count, dp.Sum)
+	}
+	if route, _ := dp.Attributes.Value("route"); route.AsString() != "/users" {
+		t.Errorf("request.duration route = %q, wants %q", route.AsString(), "/users")
+	}
+}
+
+func TestWithLogMetrics_first(t *testing.T) {
+	opts := &logExporterOptions{}
+	WithLogFilter(FilterAll()).apply(opts)
+	WithLogMetrics(nil).apply(opts)
+
+	last := &recordingProcessor{}
+	processor := chainLogProcessors(last, opts.stages)
+	if _, ok := processor.(*metricsProcessor); ok == false {
+		t.Errorf("first processor is %T, wants *metricsProcessor", processor)
+	}
+}
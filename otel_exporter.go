@@ -2,48 +2,109 @@ package otelog
 
 import (
 	"context"
+	"io"
 
 	"github.com/atuleu/otelog/internal/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
 	collector "go.opentelemetry.io/proto/otlp/collector/logs/v1"
 	common "go.opentelemetry.io/proto/otlp/common/v1"
 	logs "go.opentelemetry.io/proto/otlp/logs/v1"
 	resource "go.opentelemetry.io/proto/otlp/resource/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 type otelExporter struct {
 	logClient collector.LogsServiceClient
+	// closer is the connection or file opened by the exporter, if
+	// any.
+	closer io.Closer
 
 	processor LogProcessor
-	scope     *common.InstrumentationScope
-	resource  *resource.Resource
+
+	instrumentationScope instrumentation.Scope
+	scope                *common.InstrumentationScope
+
+	resource          *resource.Resource
+	resourceSchemaURL string
 }
 
 func (e *otelExporter) Export(record *logs.LogRecord) {
-	e.processor.batch(record, e.sendBatch)
+	e.ExportContext(context.Background(), record)
 }
 
-func (e *otelExporter) sendBatch(records []*logs.LogRecord) {
-	e.logClient.Export(context.Background(),
+func (e *otelExporter) ExportContext(ctx context.Context, record *logs.LogRecord) {
+	if _, ok := ctx.Value(scopeContextKey{}).(instrumentation.Scope); ok == false {
+		ctx = contextWithInstrumentationScope(ctx, e.instrumentationScope)
+	}
+	if err := e.processor.OnEmit(ctx, record); err != nil {
+		otel.Handle(err)
+	}
+}
+
+func (e *otelExporter) ForceFlush(ctx context.Context) error {
+	return e.processor.ForceFlush(ctx)
+}
+
+func (e *otelExporter) Shutdown(ctx context.Context) error {
+	err := e.processor.Shutdown(ctx)
+	if e.closer != nil {
+		if cerr := e.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (e *otelExporter) sendBatch(ctx context.Context, batch []scopedRecord) error {
+	_, err := e.logClient.Export(ctx,
 		&collector.ExportLogsServiceRequest{
 			ResourceLogs: []*logs.ResourceLogs{
 				{
-					Resource: e.resource,
-					ScopeLogs: []*logs.ScopeLogs{
-						{
-							Scope:      e.scope,
-							LogRecords: records,
-						},
-					},
+					Resource:  e.resource,
+					ScopeLogs: e.groupByScope(batch),
+					SchemaUrl: e.resourceSchemaURL,
 				},
 			},
 		})
+	return err
+}
+
+// groupByScope groups the records of batch by instrumentation scope,
+// in order of first appearance.
+func (e *otelExporter) groupByScope(batch []scopedRecord) []*logs.ScopeLogs {
+	var res []*logs.ScopeLogs
+	indexes := make(map[instrumentation.Scope]int)
+	for _, r := range batch {
+		idx, ok := indexes[r.scope]
+		if ok == false {
+			idx = len(res)
+			indexes[r.scope] = idx
+			res = append(res, &logs.ScopeLogs{
+				Scope:     e.protoScope(r.scope),
+				SchemaUrl: r.scope.SchemaURL,
+			})
+		}
+		res[idx].LogRecords = append(res[idx].LogRecords, r.record)
+	}
+	return res
+}
+
+// protoScope returns the InstrumentationScope to export for
+// scope. Only the scope of the exporter has attributes.
+func (e *otelExporter) protoScope(scope instrumentation.Scope) *common.InstrumentationScope {
+	if scope == e.instrumentationScope {
+		return e.scope
+	}
+	return &common.InstrumentationScope{Name: scope.Name, Version: scope.Version}
 }
 
 func buildScope(opts logExporterOptions) *common.InstrumentationScope {
 	return &common.InstrumentationScope{
-		Name:    opts.scope.Name,
-		Version: opts.scope.Version,
+		Name:       opts.scope.Name,
+		Version:    opts.scope.Version,
+		Attributes: utils.KeyValues(opts.scopeAttributes),
 	}
 }
 
@@ -51,36 +112,80 @@ func buildResource(opts logExporterOptions) *resource.Resource {
 	if opts.resource == nil {
 		return nil
 	}
+	attributes := opts.resource.Attributes()
+	dropped := 0
+	if opts.resourceAttributeCountLimit > 0 && len(attributes) > opts.resourceAttributeCountLimit {
+		dropped = len(attributes) - opts.resourceAttributeCountLimit
+		attributes = attributes[:opts.resourceAttributeCountLimit]
+	}
+
 	return &resource.Resource{
-		Attributes: utils.KeyValues(opts.resource.Attributes()),
+		Attributes:             utils.KeyValues(attributes),
+		DroppedAttributesCount: uint32(dropped),
 	}
+}
 
+func buildResourceSchemaURL(opts logExporterOptions) string {
+	if opts.resource == nil {
+		return ""
+	}
+	return opts.resource.SchemaURL()
 }
 
 // Creates a new LogExporter that will export LogRecord to the
 // specified endpoint. The endpoint address must be specified with
 // WithEndpoint(). Credential must be specified with either
-// WithInsecure() or WithTLSCredential().
-func NewLogExporter(options ...LogExporterOption) (LogExporter, error) {
+// WithInsecure() or WithTLSCredential(). Alternatively, records can
+// be exported with OTLP/HTTP with WithHTTPEndpoint(), or written as
+// OTLP JSON with WithFile() or WithWriter().
+//
+// The exported Resource is detected from the environment, and merged
+// under the one provided with WithResource().
+func NewLogExporter(options ...LogExporterOption) (FlushableLogExporter, error) {
 	opts := newOtelLogExporterOptions(options...)
+	opts.resource = mergeResources(detectDefaultResource(context.Background()), opts.resource)
+
+	client, closer, err := newLogsClient(opts)
+	if err != nil {
+		return nil, err
+	}
 
-	if opts.conn == nil {
-		var err error
-		opts.conn, err = grpc.Dial(opts.endpoint,
-			grpc.WithTransportCredentials(opts.credential),
-		)
+	res := newOtelExporter(opts, client)
+	res.closer = closer
+	return res, nil
+}
+
+// dial dials the endpoint of opts.
+func dial(opts logExporterOptions) (*grpc.ClientConn, error) {
+	credential := opts.credential
+	if opts.certificates != nil {
+		config, err := opts.certificates.tlsConfig(endpointHost(opts.endpoint))
 		if err != nil {
 			return nil, err
 		}
+		credential = credentials.NewTLS(config)
 	}
+	dialOptions := []grpc.DialOption{grpc.WithTransportCredentials(credential)}
+	if opts.tokens != nil {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(opts.tokenCredentials()))
+	}
+	return grpc.Dial(opts.endpoint, dialOptions...)
+}
 
-	client := collector.NewLogsServiceClient(opts.conn)
-
-	return &otelExporter{
-		logClient: client,
-		resource:  buildResource(opts),
-		scope:     buildScope(opts),
-		processor: opts.processor,
-	}, nil
-
+// newOtelExporter creates an exporter from opts, with a resolved
+// resource, sending records with client.
+func newOtelExporter(opts logExporterOptions, client collector.LogsServiceClient) *otelExporter {
+	res := &otelExporter{
+		logClient:            client,
+		resource:             buildResource(opts),
+		resourceSchemaURL:    buildResourceSchemaURL(opts),
+		instrumentationScope: opts.scope,
+		scope:                buildScope(opts),
+	}
+	limits := &limitsProcessor{
+		chainedProcessor: chainedProcessor{opts.newProcessor(res.sendBatch)},
+		limits:           opts.limits,
+	}
+	res.processor = chainLogProcessors(limits, opts.stages)
+	return res
 }
@@ -1,6 +1,10 @@
 package otelog
 
 import (
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"google.golang.org/grpc"
@@ -9,13 +13,24 @@ import (
 )
 
 type logExporterOptions struct {
-	conn       *grpc.ClientConn
-	endpoint   string
-	credential credentials.TransportCredentials
-
-	resource  *resource.Resource
-	scope     instrumentation.Scope
-	processor LogProcessor
+	conn         *grpc.ClientConn
+	endpoint     string
+	credential   credentials.TransportCredentials
+	httpEndpoint string
+	filePath     string
+	writer       io.Writer
+	certificates *certificateFiles
+
+	tokens              TokenSource
+	allowInsecureTokens bool
+
+	resource                    *resource.Resource
+	resourceAttributeCountLimit int
+	scope                       instrumentation.Scope
+	scopeAttributes             []attribute.KeyValue
+	newProcessor                func(logBatchCallback) LogProcessor
+	stages                      []LogProcessorStage
+	limits                      LogRecordLimits
 }
 
 // LogExporterOption is an option to use with NewLogExporter().
@@ -29,13 +44,25 @@ func (f logExporterOptionFunc) apply(opts *logExporterOptions) {
 	f(opts)
 }
 
-// Sets the resource associated with the LogExporter
+// Sets the resource associated with the LogExporter. Its attributes
+// take precedence over the ones detected from the environment, like
+// OTEL_SERVICE_NAME or OTEL_RESOURCE_ATTRIBUTES.
 func WithResource(r *resource.Resource) LogExporterOption {
 	return logExporterOptionFunc(func(opts *logExporterOptions) {
 		opts.resource = r
 	})
 }
 
+// Sets the maximal number of resource attributes exported. Any
+// attributes above this limit are dropped and reported in the
+// exported Resource DroppedAttributesCount. A limit of zero or less
+// means no limit, which is the default.
+func WithResourceAttributeCountLimit(limit int) LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		opts.resourceAttributeCountLimit = limit
+	})
+}
+
 type scopeOption instrumentation.Scope
 
 // Sets the scope associated with the LogExporter
@@ -45,18 +72,45 @@ func WithScope(s instrumentation.Scope) LogExporterOption {
 	})
 }
 
+// Sets the attributes of the scope associated with the LogExporter.
+func WithScopeAttributes(attrs ...attribute.KeyValue) LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		opts.scopeAttributes = append(opts.scopeAttributes, attrs...)
+	})
+}
+
 // Sets a LogProcessor that sends every event immediatly. It
 // should be avoided in production.
 func WithSyncer() LogExporterOption {
 	return logExporterOptionFunc(func(opts *logExporterOptions) {
-		opts.processor = &syncProcessor{}
+		opts.newProcessor = newSyncProcessor
 	})
 }
 
-// Sets a LogProcessor that batches logs before exporting them.
+// Sets a LogProcessor that batches logs before exporting them. This
+// is the default.
 func WithBatchLogProcessor(options ...BatchLogProcessorOption) LogExporterOption {
 	return logExporterOptionFunc(func(opts *logExporterOptions) {
-		opts.processor = newBatchProcessor(options...)
+		opts.newProcessor = func(callback logBatchCallback) LogProcessor {
+			return newBatchProcessor(callback, options...)
+		}
+	})
+}
+
+// Adds a LogProcessor in the pipeline, in front of the one selected
+// with WithSyncer() or WithBatchLogProcessor(). LogProcessor are
+// called in the order they are added.
+func WithLogProcessor(stage LogProcessorStage) LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		opts.stages = append(opts.stages, stage)
+	})
+}
+
+// Sets the limits applied to every LogRecord after all LogProcessor,
+// before they are batched. Defaults to NewLogRecordLimits().
+func WithLogRecordLimits(limits LogRecordLimits) LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		opts.limits = limits
 	})
 }
 
@@ -81,15 +135,88 @@ func WithTLSCredentials(c credentials.TransportCredentials) LogExporterOption {
 	})
 }
 
+// Authenticates with the client certificate and key in the PEM files
+// certFile and keyFile, and verifies the collector with the CA in
+// caFile, or the system roots if caFile is empty. It is used with
+// gRPC and OTLP/HTTP instead of WithTLSCredentials(). The files are
+// checked on every export and TLS handshake, and reloaded when they
+// change. The exporter then reconnects, so rotated certificates are
+// used without recreating it. If a reload fails, the error is
+// reported with otel.Handle() and the last valid certificate stays in
+// use.
+func WithClientCertificateFiles(certFile, keyFile, caFile string) LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		opts.certificates = newCertificateFiles(certFile, keyFile, caFile)
+	})
+}
+
 func WithGRPCConn(conn *grpc.ClientConn) LogExporterOption {
 	return logExporterOptionFunc(func(opts *logExporterOptions) {
 		opts.conn = conn
 	})
 }
 
+// Exports logs with OTLP/HTTP to url, for example
+// "http://localhost:4318/v1/logs", instead of gRPC.
+func WithHTTPEndpoint(url string) LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		opts.httpEndpoint = url
+	})
+}
+
+// Appends logs to the file at path, as lines of OTLP JSON, instead of
+// exporting them to a collector. The file is closed on Shutdown.
+func WithFile(path string) LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		opts.filePath = path
+	})
+}
+
+// Writes logs to w, as lines of OTLP JSON, instead of exporting them
+// to a collector. It can be used with os.Stdout to print logs to the
+// console.
+func WithWriter(w io.Writer) LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		opts.writer = w
+	})
+}
+
+// Sends the tokens of source as bearer tokens to the collector, with
+// gRPC or OTLP/HTTP. Tokens are cached until shortly before their
+// expiry. They are only sent over TLS, unless WithInsecureTokens() is
+// used.
+func WithBearerToken(source TokenSource) LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		opts.tokens = newCachingTokenSource(source)
+	})
+}
+
+// Sends bearer tokens fetched from tokenURL with the OAuth2 client
+// credentials grant. See WithBearerToken().
+func WithOAuth2ClientCredentials(tokenURL, clientID, clientSecret string, scopes []string) LogExporterOption {
+	return WithBearerToken(&clientCredentialsSource{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+		client:       &http.Client{Timeout: tokenRequestTimeout},
+	})
+}
+
+// Allows bearer tokens to be sent over insecure transports. It should
+// only be used with a local collector.
+func WithInsecureTokens() LogExporterOption {
+	return logExporterOptionFunc(func(opts *logExporterOptions) {
+		opts.allowInsecureTokens = true
+	})
+}
+
 func newOtelLogExporterOptions(options ...LogExporterOption) logExporterOptions {
 	opts := logExporterOptions{
-		processor: newBatchProcessor(),
+		newProcessor: func(callback logBatchCallback) LogProcessor {
+			return newBatchProcessor(callback)
+		},
+		limits: NewLogRecordLimits(),
 	}
 
 	for _, o := range options {
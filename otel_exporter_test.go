@@ -0,0 +1,66 @@
+package otelog
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestBuildResource_preservesSchemaURL(t *testing.T) {
+	opts := newOtelLogExporterOptions(WithResource(
+		resource.NewWithAttributes("https://example.com/schema",
+			attribute.String("service.name", "test"),
+		)))
+
+	if url := buildResourceSchemaURL(opts); url != "https://example.com/schema" {
+		t.Errorf("schema URL = %q, wants %q", url, "https://example.com/schema")
+	}
+
+	res := buildResource(opts)
+	if len(res.Attributes) != 1 {
+		t.Fatalf("len(Attributes) = %d, wants 1", len(res.Attributes))
+	}
+	if res.DroppedAttributesCount != 0 {
+		t.Errorf("DroppedAttributesCount = %d, wants 0", res.DroppedAttributesCount)
+	}
+}
+
+func TestBuildResource_reportsDroppedAttributes(t *testing.T) {
+	opts := newOtelLogExporterOptions(
+		WithResource(resource.NewSchemaless(
+			attribute.String("a", "a"),
+			attribute.String("b", "b"),
+			attribute.String("c", "c"),
+		)),
+		WithResourceAttributeCountLimit(2),
+	)
+
+	res := buildResource(opts)
+	if len(res.Attributes) != 2 {
+		t.Errorf("len(Attributes) = %d, wants 2", len(res.Attributes))
+	}
+	if res.DroppedAttributesCount != 1 {
+		t.Errorf("DroppedAttributesCount = %d, wants 1", res.DroppedAttributesCount)
+	}
+}
+
+func TestBuildScope_withAttributes(t *testing.T) {
+	opts := newOtelLogExporterOptions(
+		WithScope(instrumentation.Scope{
+			Name:      "foo",
+			Version:   "v1.2.3",
+			SchemaURL: "https://example.com/schema",
+		}),
+		WithScopeAttributes(attribute.Int("bar", 42)),
+	)
+
+	scope := buildScope(opts)
+	if scope.Name != "foo" || scope.Version != "v1.2.3" {
+		t.Errorf("scope = %s@%s, wants foo@v1.2.3", scope.Name, scope.Version)
+	}
+	if len(scope.Attributes) != 1 || scope.Attributes[0].Key != "bar" {
+		t.Errorf("unexpected scope attributes %v", scope.Attributes)
+	}
+}
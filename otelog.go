@@ -14,6 +14,18 @@
 // Then you can use hooks in `github.com/atuleu/otelog/pkg/hooks` to
 // integrate your logging library. Currently only
 // `github.com/sirupsen/logrus` integration is provided.
+//
+// Records can also be emitted directly, with a Logger obtained from
+// GetLoggerProvider():
+//
+//	logger := otelog.GetLoggerProvider().Logger("github.com/example/app", "v1.0.0")
+//	logger.Emit(ctx, otelog.NewRecord(otelog.SeverityInfo, "request done"))
+//
+// # Processing
+//
+// Before being exported, every LogRecord goes through a pipeline of
+// LogProcessor, that can be extended with WithLogProcessor() to
+// filter, enrich, redact or transform records.
 package otelog
 
 var globalExporter LogExporter = NoopLogExporter()
@@ -0,0 +1,182 @@
+// Package config loads the description of a log pipeline from a YAML
+// or JSON document, and builds the corresponding otelog.LogExporter.
+//
+// A document looks like:
+//
+//	resource:
+//	  service.name: my-service
+//	  deployment.environment: ${ENVIRONMENT:-dev}
+//	exporters:
+//	  - type: grpc
+//	    endpoint: ${OTEL_COLLECTOR}
+//	    insecure: true
+//	    batch_timeout: 5s
+//	  - type: console
+//	processors:
+//	  - drop: severity_number < SEVERITY_NUMBER_INFO
+//	  - redaction:
+//	      detectors: [email, credit_card]
+//	  - sampling:
+//	      ratio: 0.1
+//	      severity_ratios:
+//	        WARN: 1
+//	logrus:
+//	  level: info
+//
+// References to environment variables, as ${NAME} or
+// ${NAME:-default}, are expanded in values. Errors report the line
+// of the document they occur at.
+//
+// A ReloadableExporter swaps its pipeline when the configuration
+// changes, without losing records.
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes a log pipeline. See Load().
+type Config struct {
+	// Resource are the attributes of the exported resource. They
+	// take precedence over the detected ones.
+	Resource map[string]string `yaml:"resource"`
+	// Exporters are the destinations of records. At least one is
+	// required.
+	Exporters []ExporterConfig `yaml:"exporters"`
+	// Processors is the chain of processors records go through, in
+	// order, before every exporter.
+	Processors []ProcessorConfig `yaml:"processors"`
+	// Logrus configures the logrus hook.
+	Logrus LogrusConfig `yaml:"logrus"`
+}
+
+// Load reads a Config from a YAML or JSON document, and validates
+// it.
+func Load(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, fmt.Errorf("empty configuration")
+	}
+	if err := expandEnv(&root, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	res := &Config{}
+	if err := root.Content[0].Decode(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// LoadFile reads a Config from the YAML or JSON file at path. See
+// Load().
+func LoadFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return res, nil
+}
+
+func (c *Config) UnmarshalYAML(node *yaml.Node) error {
+	type plain Config
+	if err := decodeStrict(node, (*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.Exporters) == 0 {
+		return fmt.Errorf("line %d: at least one exporter is required", node.Line)
+	}
+	return nil
+}
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv expands the references to environment variables in the
+// scalar values of node.
+func expandEnv(node *yaml.Node, lookup func(string) (string, bool)) error {
+	if node.Kind == yaml.ScalarNode {
+		var err error
+		expanded := envReference.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			m := envReference.FindStringSubmatch(ref)
+			if value, ok := lookup(m[1]); ok == true {
+				return value
+			}
+			if len(m[2]) > 0 {
+				return m[3]
+			}
+			if err == nil {
+				err = fmt.Errorf("line %d: environment variable %s is not set", node.Line, m[1])
+			}
+			return ""
+		})
+		if err != nil {
+			return err
+		}
+		if expanded != node.Value {
+			node.Value = expanded
+			if node.Style == 0 {
+				// lets the expanded value be resolved as a number or boolean.
+				node.Tag = ""
+			}
+		}
+		return nil
+	}
+	for _, child := range node.Content {
+		if err := expandEnv(child, lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeStrict decodes node in out, a pointer to a struct, and reports
+// any key of node that is not a field of out.
+func decodeStrict(node *yaml.Node, out interface{}) error {
+	if node.Kind == yaml.MappingNode {
+		fields := yamlFields(reflect.TypeOf(out).Elem())
+		for i := 0; i < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if slices.Contains(fields, key.Value) == false {
+				return fmt.Errorf("line %d: unknown field %q, expected one of %s",
+					key.Line, key.Value, strings.Join(fields, ", "))
+			}
+		}
+	}
+	return node.Decode(out)
+}
+
+// yamlFields returns the keys of the exported fields of t.
+func yamlFields(t reflect.Type) []string {
+	var res []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() == false {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if len(name) == 0 {
+			name = strings.ToLower(f.Name)
+		}
+		res = append(res, name)
+	}
+	return res
+}
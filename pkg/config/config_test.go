@@ -0,0 +1,240 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atuleu/otelog"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("OTELOG_TEST_COLLECTOR", "collector:4317")
+
+	config, err := Load(strings.NewReader(`
+resource:
+  service.name: test
+  deployment.environment: ${OTELOG_TEST_ENVIRONMENT:-dev}
+exporters:
+  - type: grpc
+    endpoint: ${OTELOG_TEST_COLLECTOR}
+    insecure: true
+    batch_timeout: 5s
+  - type: console
+    sync: true
+processors:
+  - drop: severity_number < SEVERITY_NUMBER_INFO
+  - transform:
+      - set(attributes["env"], "test")
+  - redaction:
+      detectors: [email]
+      mode: remove
+  - sampling:
+      ratio: 0.5
+      severity_ratios:
+        warn: 1
+logrus:
+  level: warn
+  span_events: error
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if config.Resource["deployment.environment"] != "dev" {
+		t.Errorf("deployment.environment = %q, wants %q", config.Resource["deployment.environment"], "dev")
+	}
+	if len(config.Exporters) != 2 {
+		t.Fatalf("got %d exporters, wants 2", len(config.Exporters))
+	}
+	expected := ExporterConfig{Type: "grpc", Endpoint: "collector:4317", Insecure: true, BatchTimeout: 5 * time.Second}
+	if config.Exporters[0] != expected {
+		t.Errorf("exporter = %+v, wants %+v", config.Exporters[0], expected)
+	}
+	names := make([]string, 0, len(config.Processors))
+	for _, p := range config.Processors {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "drop,transform,redaction,sampling" {
+		t.Errorf("processors = %v", names)
+	}
+	if len(config.Logrus.Options()) != 2 {
+		t.Errorf("got %d logrus options, wants 2", len(config.Logrus.Options()))
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	config, err := Load(strings.NewReader(`{"exporters": [{"type": "file", "path": "out.jsonl"}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if config.Exporters[0].Path != "out.jsonl" {
+		t.Errorf("path = %q, wants %q", config.Exporters[0].Path, "out.jsonl")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	testdata := []struct {
+		Document string
+		Error    string
+	}{
+		{"", "empty configuration"},
+		{"resource: {}\n", "line 1: at least one exporter is required"},
+		{"exporters:\n  - type: grpc\n    endpont: localhost\n", `line 3: unknown field "endpont"`},
+		{"exporters:\n  - type: grpc\n", "line 2: grpc exporter requires an endpoint"},
+		{"exporters:\n  - type: kafka\n", `line 2: unknown exporter type "kafka"`},
+		{"exporters:\n  - type: console\nprocessors:\n  - compress: true\n", `line 4: unknown processor "compress"`},
+		{"exporters:\n  - type: console\nprocessors:\n  - drop: severity_number <\n", "line 4: invalid drop processor"},
+		{"exporters:\n  - type: console\nprocessors:\n  - sampling:\n      severity_ratios: {LOUD: 1}\n", `line 5: invalid sampling processor: unknown severity "LOUD"`},
+		{"exporters:\n  - type: console\nprocessors:\n  - dedup:\n      window: 1s\n      max_groups: -1\n", "line 5: invalid dedup processor: max_groups must not be negative"},
+		{"exporters:\n  - type: console\nprocessors:\n  - rate_limit:\n      rate: 1\n      burst: -1\n", "line 5: invalid rate_limit processor: burst must not be negative"},
+		{"exporters:\n  - type: console\nlogrus:\n  level: loud\n", "line 4: not a valid logrus Level"},
+		{"exporters:\n  - type: grpc\n    endpoint: ${OTELOG_TEST_UNSET}\n", "line 3: environment variable OTELOG_TEST_UNSET is not set"},
+	}
+
+	for _, d := range testdata {
+		_, err := Load(strings.NewReader(d.Document))
+		if err == nil {
+			t.Errorf("expected an error for %q", d.Document)
+			continue
+		}
+		if strings.Contains(err.Error(), d.Error) == false {
+			t.Errorf("error for %q = %q, wants %q", d.Document, err, d.Error)
+		}
+	}
+}
+
+func TestConfig_NewLogExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.jsonl")
+	config, err := Load(strings.NewReader(`
+resource:
+  service.name: config-test
+exporters:
+  - type: file
+    path: ` + path + `
+    sync: true
+processors:
+  - drop: body == "dropped"
+  - attributes:
+      origin: config
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	exporter, err := config.NewLogExporter()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ctx := context.Background()
+	logger := otelog.NewLoggerProvider(exporter).Logger("config", "")
+	logger.Emit(ctx, otelog.NewRecord(otelog.SeverityInfo, "dropped"))
+	logger.Emit(ctx, otelog.NewRecord(otelog.SeverityInfo, "kept"))
+	if err := exporter.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d exported requests, wants 1", len(lines))
+	}
+	var request map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &request); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, expected := range []string{"config-test", `"kept"`, "origin"} {
+		if strings.Contains(lines[0], expected) == false {
+			t.Errorf("exported %s does not contain %s", lines[0], expected)
+		}
+	}
+	if strings.Contains(lines[0], "dropped") == true {
+		t.Errorf("exported %s contains a dropped record", lines[0])
+	}
+}
+
+func TestConfig_rateLimitDefaultBurst(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.jsonl")
+	config, err := Load(strings.NewReader(`
+exporters:
+  - type: file
+    path: ` + path + `
+    sync: true
+processors:
+  - rate_limit:
+      rate: 1
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	exporter, err := config.NewLogExporter()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ctx := context.Background()
+	logger := otelog.NewLoggerProvider(exporter).Logger("config", "")
+	logger.Emit(ctx, otelog.NewRecord(otelog.SeverityInfo, "limited"))
+	logger.Emit(ctx, otelog.NewRecord(otelog.SeverityInfo, "limited"))
+	if err := exporter.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := strings.Count(string(data), "limited"); n != 1 {
+		t.Errorf("exported %d records, wants 1", n)
+	}
+}
+
+func TestConfig_sharedProcessors(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "first.jsonl"), filepath.Join(dir, "second.jsonl")}
+	config, err := Load(strings.NewReader(`
+exporters:
+  - type: file
+    path: ` + paths[0] + `
+    sync: true
+  - type: file
+    path: ` + paths[1] + `
+    sync: true
+processors:
+  - sampling:
+      ratio: 0.5
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	exporter, err := config.NewLogExporter()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ctx := context.Background()
+	logger := otelog.NewLoggerProvider(exporter).Logger("config", "")
+	for i := 0; i < 100; i++ {
+		logger.Emit(ctx, otelog.NewRecord(otelog.SeverityInfo, fmt.Sprintf("record %d", i)))
+	}
+	if err := exporter.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var data []string
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		data = append(data, string(content))
+	}
+	if data[0] != data[1] {
+		t.Errorf("exporters received different records")
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/atuleu/otelog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"google.golang.org/grpc/credentials"
+	"gopkg.in/yaml.v3"
+)
+
+// ExporterConfig describes a destination of records.
+type ExporterConfig struct {
+	// Type is one of grpc, http, file or console.
+	Type string `yaml:"type"`
+	// Endpoint is the address of the collector for grpc, like
+	// "localhost:4317", or its URL for http, like
+	// "http://localhost:4318/v1/logs".
+	Endpoint string `yaml:"endpoint"`
+	// Insecure disables TLS for grpc.
+	Insecure bool `yaml:"insecure"`
+	// Path is the file records are appended to for file.
+	Path string `yaml:"path"`
+
+	// Sync exports every record synchronously, instead of in batches.
+	Sync bool `yaml:"sync"`
+	// BatchTimeout and MaxQueueSize configure the batches, see
+	// otelog.WithBatchTimeout() and otelog.WithMaxQueueSize().
+	BatchTimeout time.Duration `yaml:"batch_timeout"`
+	MaxQueueSize int           `yaml:"max_queue_size"`
+}
+
+func (c *ExporterConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain ExporterConfig
+	if err := decodeStrict(node, (*plain)(c)); err != nil {
+		return err
+	}
+	switch c.Type {
+	case "grpc", "http":
+		if len(c.Endpoint) == 0 {
+			return fmt.Errorf("line %d: %s exporter requires an endpoint", node.Line, c.Type)
+		}
+	case "file":
+		if len(c.Path) == 0 {
+			return fmt.Errorf("line %d: file exporter requires a path", node.Line)
+		}
+	case "console":
+	default:
+		return fmt.Errorf("line %d: unknown exporter type %q, expected one of grpc, http, file, console",
+			node.Line, c.Type)
+	}
+	return nil
+}
+
+func (c ExporterConfig) options() []otelog.LogExporterOption {
+	var res []otelog.LogExporterOption
+	switch c.Type {
+	case "grpc":
+		res = append(res, otelog.WithEndpoint(c.Endpoint))
+		if c.Insecure == true {
+			res = append(res, otelog.WithInsecure())
+		} else {
+			res = append(res, otelog.WithTLSCredentials(credentials.NewTLS(&tls.Config{})))
+		}
+	case "http":
+		res = append(res, otelog.WithHTTPEndpoint(c.Endpoint))
+	case "file":
+		res = append(res, otelog.WithFile(c.Path))
+	case "console":
+		res = append(res, otelog.WithWriter(os.Stdout))
+	}
+
+	if c.Sync == true {
+		return append(res, otelog.WithSyncer())
+	}
+	var batchOptions []otelog.BatchLogProcessorOption
+	if c.BatchTimeout > 0 {
+		batchOptions = append(batchOptions, otelog.WithBatchTimeout(c.BatchTimeout))
+	}
+	if c.MaxQueueSize > 0 {
+		batchOptions = append(batchOptions, otelog.WithMaxQueueSize(c.MaxQueueSize))
+	}
+	return append(res, otelog.WithBatchLogProcessor(batchOptions...))
+}
+
+// NewLogExporter builds the LogExporter described by c. If several
+// exporters are configured, records are exported to all of them. The
+// processors are shared by all exporters, so records are processed
+// once, before being exported.
+func (c *Config) NewLogExporter() (otelog.FlushableLogExporter, error) {
+	var common []otelog.LogExporterOption
+	if len(c.Resource) > 0 {
+		attrs := make([]attribute.KeyValue, 0, len(c.Resource))
+		for k, v := range c.Resource {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+		common = append(common, otelog.WithResource(resource.NewSchemaless(attrs...)))
+	}
+
+	exporters := make([]otelog.FlushableLogExporter, 0, len(c.Exporters))
+	for _, e := range c.Exporters {
+		options := append(append([]otelog.LogExporterOption(nil), common...), e.options()...)
+		exporter, err := otelog.NewLogExporter(options...)
+		if err != nil {
+			for _, created := range exporters {
+				created.Shutdown(context.Background())
+			}
+			return nil, fmt.Errorf("could not create %s exporter: %w", e.Type, err)
+		}
+		exporters = append(exporters, exporter)
+	}
+
+	exporter := exporters[0]
+	if len(exporters) > 1 {
+		multi := make([]otelog.LogExporter, 0, len(exporters))
+		for _, e := range exporters {
+			multi = append(multi, e)
+		}
+		exporter = otelog.NewMultiLogExporter(multi...)
+	}
+	if len(c.Processors) == 0 {
+		return exporter, nil
+	}
+	stages := make([]otelog.LogProcessorStage, 0, len(c.Processors))
+	for _, p := range c.Processors {
+		stages = append(stages, p.stage)
+	}
+	return otelog.NewProcessingLogExporter(exporter, stages...), nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/atuleu/otelog/pkg/hooks"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// LogrusConfig configures the logrus hook, see hooks.NewLogrusHook().
+type LogrusConfig struct {
+	// Level is the least severe exported level. Defaults to info.
+	Level string `yaml:"level"`
+	// SpanEvents is the least severe level added as span events. No
+	// entries are added as span events if empty.
+	SpanEvents string `yaml:"span_events"`
+	// SpanErrorStatus sets the status of spans to error for error
+	// entries added as span events.
+	SpanErrorStatus bool `yaml:"span_error_status"`
+}
+
+func (c *LogrusConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain LogrusConfig
+	if err := decodeStrict(node, (*plain)(c)); err != nil {
+		return err
+	}
+	for _, level := range []string{c.Level, c.SpanEvents} {
+		if len(level) == 0 {
+			continue
+		}
+		if _, err := logrus.ParseLevel(level); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+	}
+	return nil
+}
+
+// Options returns the options of the logrus hook described by c.
+func (c LogrusConfig) Options() []hooks.LogrusOption {
+	level := logrus.InfoLevel
+	if len(c.Level) > 0 {
+		level, _ = logrus.ParseLevel(c.Level)
+	}
+	res := []hooks.LogrusOption{hooks.FromLogrusLevel(level)}
+	if len(c.SpanEvents) > 0 {
+		spanLevel, _ := logrus.ParseLevel(c.SpanEvents)
+		res = append(res, hooks.WithSpanEvents(spanLevel))
+	}
+	if c.SpanErrorStatus == true {
+		res = append(res, hooks.WithSpanErrorStatus())
+	}
+	return res
+}
@@ -0,0 +1,385 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/atuleu/otelog"
+	"go.opentelemetry.io/otel/attribute"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// ProcessorConfig describes a stage of the processor chain. It is a
+// mapping with a single key, naming the processor, to its
+// configuration:
+//
+//   - drop: a condition, see otelog.ParseLogFilter().
+//   - transform: a list of statements, see otelog.ParseLogTransform().
+//   - attributes: a mapping of static attributes to add to records.
+//   - redaction: see RedactionConfig.
+//   - sampling: see SamplingConfig.
+//   - rate_limit: see RateLimitConfig.
+//   - dedup: see DedupConfig.
+//   - tail: see TailConfig.
+type ProcessorConfig struct {
+	// Name of the processor.
+	Name string
+
+	stage otelog.LogProcessorStage
+}
+
+var processorNames = []string{"drop", "transform", "attributes", "redaction", "sampling", "rate_limit", "dedup", "tail"}
+
+// processorConfig is the configuration of a named processor.
+type processorConfig interface {
+	stage() (otelog.LogProcessorStage, error)
+}
+
+func (c *ProcessorConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode || len(node.Content) != 2 {
+		return fmt.Errorf("line %d: a processor must be a mapping with a single key, one of %s",
+			node.Line, strings.Join(processorNames, ", "))
+	}
+	key, value := node.Content[0], node.Content[1]
+
+	var config processorConfig
+	switch key.Value {
+	case "drop":
+		config = &dropConfig{}
+	case "transform":
+		config = &transformConfig{}
+	case "attributes":
+		config = &attributesConfig{}
+	case "redaction":
+		config = &RedactionConfig{}
+	case "sampling":
+		config = &SamplingConfig{}
+	case "rate_limit":
+		config = &RateLimitConfig{}
+	case "dedup":
+		config = &DedupConfig{}
+	case "tail":
+		config = &TailConfig{}
+	default:
+		return fmt.Errorf("line %d: unknown processor %q, expected one of %s",
+			key.Line, key.Value, strings.Join(processorNames, ", "))
+	}
+	if err := value.Decode(config); err != nil {
+		return err
+	}
+	stage, err := config.stage()
+	if err != nil {
+		return fmt.Errorf("line %d: invalid %s processor: %w", value.Line, key.Value, err)
+	}
+	c.Name = key.Value
+	c.stage = stage
+	return nil
+}
+
+type dropConfig struct {
+	condition string
+}
+
+func (c *dropConfig) UnmarshalYAML(node *yaml.Node) error {
+	return node.Decode(&c.condition)
+}
+
+func (c *dropConfig) stage() (otelog.LogProcessorStage, error) {
+	filter, err := otelog.ParseLogFilter(c.condition)
+	if err != nil {
+		return nil, err
+	}
+	return func(next otelog.LogProcessor) otelog.LogProcessor {
+		return otelog.NewFilterProcessor(next, filter)
+	}, nil
+}
+
+type transformConfig struct {
+	statements []string
+}
+
+func (c *transformConfig) UnmarshalYAML(node *yaml.Node) error {
+	return node.Decode(&c.statements)
+}
+
+func (c *transformConfig) stage() (otelog.LogProcessorStage, error) {
+	transform, err := otelog.ParseLogTransform(c.statements...)
+	if err != nil {
+		return nil, err
+	}
+	return func(next otelog.LogProcessor) otelog.LogProcessor {
+		return otelog.NewTransformProcessor(next, transform)
+	}, nil
+}
+
+type attributesConfig struct {
+	attributes map[string]string
+}
+
+func (c *attributesConfig) UnmarshalYAML(node *yaml.Node) error {
+	return node.Decode(&c.attributes)
+}
+
+func (c *attributesConfig) stage() (otelog.LogProcessorStage, error) {
+	attrs := make([]attribute.KeyValue, 0, len(c.attributes))
+	for k, v := range c.attributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return func(next otelog.LogProcessor) otelog.LogProcessor {
+		return otelog.NewEnrichmentProcessor(next, otelog.WithStaticAttributes(attrs...))
+	}, nil
+}
+
+// RedactionConfig configures the redaction processor, see
+// otelog.WithRedaction().
+type RedactionConfig struct {
+	// Detectors are the names of the built-in detectors to use,
+	// defaults to all of them.
+	Detectors []string `yaml:"detectors"`
+	// Patterns are additional detectors, by name.
+	Patterns map[string]string `yaml:"patterns"`
+	// Keys are the attribute keys which values are always redacted.
+	Keys []string `yaml:"keys"`
+	// Mode is one of mask, remove or hmac. Defaults to mask.
+	Mode string `yaml:"mode"`
+	// HMACKey is the hex encoded key for the hmac mode.
+	HMACKey string `yaml:"hmac_key"`
+}
+
+func (c *RedactionConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain RedactionConfig
+	return decodeStrict(node, (*plain)(c))
+}
+
+func (c *RedactionConfig) stage() (otelog.LogProcessorStage, error) {
+	var options []otelog.RedactionOption
+	if len(c.Detectors) > 0 {
+		available := make(map[string]otelog.RedactionDetector)
+		for _, d := range otelog.DefaultRedactionDetectors() {
+			available[d.Name] = d
+		}
+		detectors := make([]otelog.RedactionDetector, 0, len(c.Detectors))
+		for _, name := range c.Detectors {
+			d, ok := available[name]
+			if ok == false {
+				return nil, fmt.Errorf("unknown detector %q", name)
+			}
+			detectors = append(detectors, d)
+		}
+		options = append(options, otelog.WithRedactionDetectors(detectors...))
+	}
+	for name, pattern := range c.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", name, err)
+		}
+		options = append(options, otelog.WithRedactionPattern(name, re))
+	}
+	if len(c.Keys) > 0 {
+		options = append(options, otelog.WithRedactedKeys(c.Keys...))
+	}
+	switch c.Mode {
+	case "", "mask":
+	case "remove":
+		options = append(options, otelog.WithRedactionMode(otelog.RedactRemove))
+	case "hmac":
+		key, err := hex.DecodeString(c.HMACKey)
+		if err != nil || len(key) == 0 {
+			return nil, fmt.Errorf("hmac mode requires a hex encoded hmac_key")
+		}
+		options = append(options, otelog.WithRedactionHMACKey(key))
+	default:
+		return nil, fmt.Errorf("unknown mode %q, expected one of mask, remove, hmac", c.Mode)
+	}
+	return func(next otelog.LogProcessor) otelog.LogProcessor {
+		return otelog.NewRedactionProcessor(next, options...)
+	}, nil
+}
+
+// SamplingConfig configures the sampling processor, see
+// otelog.WithSampling().
+type SamplingConfig struct {
+	// Ratio of records kept.
+	Ratio *float64 `yaml:"ratio"`
+	// SeverityRatios are the ratios of records kept at or above a
+	// severity, like WARN or ERROR.
+	SeverityRatios map[string]float64 `yaml:"severity_ratios"`
+	// KeepSampledTraces keeps all records of sampled traces.
+	KeepSampledTraces *bool `yaml:"keep_sampled_traces"`
+}
+
+func (c *SamplingConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain SamplingConfig
+	return decodeStrict(node, (*plain)(c))
+}
+
+func (c *SamplingConfig) stage() (otelog.LogProcessorStage, error) {
+	var options []otelog.SamplingOption
+	if c.Ratio != nil {
+		options = append(options, otelog.WithDefaultSamplingRatio(*c.Ratio))
+	}
+	for name, ratio := range c.SeverityRatios {
+		severity, err := parseSeverity(name)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, otelog.WithSeverityRatio(severity, ratio))
+	}
+	if c.KeepSampledTraces != nil {
+		options = append(options, otelog.WithSampledTracesKept(*c.KeepSampledTraces))
+	}
+	return func(next otelog.LogProcessor) otelog.LogProcessor {
+		return otelog.NewSamplingProcessor(next, options...)
+	}, nil
+}
+
+// RateLimitConfig configures the rate limiting processor, see
+// otelog.WithRateLimiter().
+type RateLimitConfig struct {
+	// Rate is the number of records per second allowed per bucket.
+	Rate float64 `yaml:"rate"`
+	// Burst is the number of records allowed at once per bucket.
+	// Defaults to the rate, rounded up.
+	Burst int `yaml:"burst"`
+	// Key selects the fields grouping records in buckets.
+	Key *RateLimitKeyConfig `yaml:"key"`
+	// MaxBuckets is the maximal number of buckets.
+	MaxBuckets int `yaml:"max_buckets"`
+}
+
+// RateLimitKeyConfig is the configuration of an otelog.RateLimitKey.
+type RateLimitKeyConfig struct {
+	Body       bool     `yaml:"body"`
+	Severity   bool     `yaml:"severity"`
+	Attributes []string `yaml:"attributes"`
+}
+
+func (c *RateLimitConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain RateLimitConfig
+	return decodeStrict(node, (*plain)(c))
+}
+
+func (c *RateLimitKeyConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain RateLimitKeyConfig
+	return decodeStrict(node, (*plain)(c))
+}
+
+func (c *RateLimitConfig) stage() (otelog.LogProcessorStage, error) {
+	if c.Rate <= 0 {
+		return nil, fmt.Errorf("rate must be positive")
+	}
+	if c.Burst < 0 {
+		return nil, fmt.Errorf("burst must not be negative")
+	}
+	options := []otelog.RateLimitOption{otelog.WithRateLimit(c.Rate, c.Burst)}
+	if c.Key != nil {
+		options = append(options, otelog.WithRateLimitKey(otelog.RateLimitKey{
+			Body:       c.Key.Body,
+			Severity:   c.Key.Severity,
+			Attributes: c.Key.Attributes,
+		}))
+	}
+	if c.MaxBuckets > 0 {
+		options = append(options, otelog.WithMaxRateLimitBuckets(c.MaxBuckets))
+	}
+	return func(next otelog.LogProcessor) otelog.LogProcessor {
+		return otelog.NewRateLimitProcessor(next, options...)
+	}, nil
+}
+
+// DedupConfig configures the deduplication processor, see
+// otelog.WithDedup().
+type DedupConfig struct {
+	// Window during which identical records are merged.
+	Window time.Duration `yaml:"window"`
+	// MaxGroups is the maximal number of distinct records tracked at
+	// once. Defaults to 10000.
+	MaxGroups int `yaml:"max_groups"`
+}
+
+func (c *DedupConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain DedupConfig
+	return decodeStrict(node, (*plain)(c))
+}
+
+func (c *DedupConfig) stage() (otelog.LogProcessorStage, error) {
+	if c.Window <= 0 {
+		return nil, fmt.Errorf("window must be positive")
+	}
+	if c.MaxGroups < 0 {
+		return nil, fmt.Errorf("max_groups must not be negative")
+	}
+	var options []otelog.DedupOption
+	if c.MaxGroups > 0 {
+		options = append(options, otelog.WithDedupMaxGroups(c.MaxGroups))
+	}
+	return func(next otelog.LogProcessor) otelog.LogProcessor {
+		return otelog.NewDedupProcessor(next, c.Window, options...)
+	}, nil
+}
+
+// TailConfig configures the tail buffering processor, see
+// otelog.WithTailBuffering().
+type TailConfig struct {
+	// BufferBelow is the severity below which records are buffered.
+	BufferBelow string `yaml:"buffer_below"`
+	// Trigger is the severity from which buffered records are
+	// flushed.
+	Trigger    string        `yaml:"trigger"`
+	BufferSize int           `yaml:"buffer_size"`
+	TTL        time.Duration `yaml:"ttl"`
+	MaxTraces  int           `yaml:"max_traces"`
+	MaxRecords int           `yaml:"max_records"`
+}
+
+func (c *TailConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain TailConfig
+	return decodeStrict(node, (*plain)(c))
+}
+
+func (c *TailConfig) stage() (otelog.LogProcessorStage, error) {
+	var options []otelog.TailOption
+	if len(c.BufferBelow) > 0 {
+		severity, err := parseSeverity(c.BufferBelow)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, otelog.WithTailBufferBelow(severity))
+	}
+	if len(c.Trigger) > 0 {
+		severity, err := parseSeverity(c.Trigger)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, otelog.WithTailTrigger(severity))
+	}
+	if c.BufferSize > 0 {
+		options = append(options, otelog.WithTailBufferSize(c.BufferSize))
+	}
+	if c.TTL > 0 {
+		options = append(options, otelog.WithTailTTL(c.TTL))
+	}
+	if c.MaxTraces > 0 {
+		options = append(options, otelog.WithTailMaxTraces(c.MaxTraces))
+	}
+	if c.MaxRecords > 0 {
+		options = append(options, otelog.WithTailMaxRecords(c.MaxRecords))
+	}
+	return func(next otelog.LogProcessor) otelog.LogProcessor {
+		return otelog.NewTailProcessor(next, options...)
+	}, nil
+}
+
+// parseSeverity parses a severity name, like INFO or ERROR2, case
+// insensitively.
+func parseSeverity(name string) (logs.SeverityNumber, error) {
+	value, ok := logs.SeverityNumber_value["SEVERITY_NUMBER_"+strings.ToUpper(name)]
+	if ok == false || value == 0 {
+		return 0, fmt.Errorf("unknown severity %q", name)
+	}
+	return logs.SeverityNumber(value), nil
+}
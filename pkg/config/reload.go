@@ -0,0 +1,151 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/atuleu/otelog"
+	"go.opentelemetry.io/otel"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// ReloadableExporter is a LogExporter which pipeline can be replaced
+// while records are exported. Each record is exported by exactly one
+// pipeline: the pipeline current when it is exported.
+type ReloadableExporter struct {
+	mx       sync.RWMutex
+	current  otelog.FlushableLogExporter
+	shutdown bool
+
+	draining sync.WaitGroup
+}
+
+// NewReloadableExporter creates a ReloadableExporter with the
+// pipeline described by config.
+func NewReloadableExporter(config *Config) (*ReloadableExporter, error) {
+	exporter, err := config.NewLogExporter()
+	if err != nil {
+		return nil, err
+	}
+	return &ReloadableExporter{current: exporter}, nil
+}
+
+// Reload builds the pipeline described by config, and swaps it with
+// the current one. The previous pipeline is drained and shut down in
+// the background. If the pipeline cannot be built, the current one is
+// kept and the error is returned.
+func (e *ReloadableExporter) Reload(config *Config) error {
+	exporter, err := config.NewLogExporter()
+	if err != nil {
+		return err
+	}
+
+	e.mx.Lock()
+	if e.shutdown == true {
+		e.mx.Unlock()
+		exporter.Shutdown(context.Background())
+		return errors.New("otelog: exporter is shut down")
+	}
+	previous := e.current
+	e.current = exporter
+	// Added while holding the lock, so Shutdown() waits for it.
+	e.draining.Add(1)
+	e.mx.Unlock()
+
+	go func() {
+		defer e.draining.Done()
+		if err := previous.Shutdown(context.Background()); err != nil {
+			otel.Handle(err)
+		}
+	}()
+	return nil
+}
+
+// ReloadFile loads the configuration at path and reloads it. See
+// Reload().
+func (e *ReloadableExporter) ReloadFile(path string) error {
+	config, err := LoadFile(path)
+	if err != nil {
+		return err
+	}
+	return e.Reload(config)
+}
+
+// WatchFile checks the file at path every interval, until ctx is
+// done. It reloads the file on the first check, so the pipeline
+// matches its content, and then whenever it changes. Reload errors
+// are reported with otel.Handle(), and leave the current pipeline
+// running.
+func (e *ReloadableExporter) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	var last os.FileInfo
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			otel.Handle(err)
+			continue
+		}
+		if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			continue
+		}
+		last = info
+		if err := e.ReloadFile(path); err != nil {
+			otel.Handle(err)
+		}
+	}
+}
+
+func (e *ReloadableExporter) Export(log *logs.LogRecord) {
+	e.ExportContext(context.Background(), log)
+}
+
+func (e *ReloadableExporter) ExportContext(ctx context.Context, log *logs.LogRecord) {
+	// The read lock ensures the pipeline is not shut down while the
+	// record is handed to it.
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+	e.current.ExportContext(ctx, log)
+}
+
+func (e *ReloadableExporter) ForceFlush(ctx context.Context) error {
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+	return e.current.ForceFlush(ctx)
+}
+
+// Shutdown shuts the current pipeline down, and waits for previous
+// pipelines to be drained.
+func (e *ReloadableExporter) Shutdown(ctx context.Context) error {
+	e.mx.Lock()
+	if e.shutdown == true {
+		e.mx.Unlock()
+		return nil
+	}
+	e.shutdown = true
+	current := e.current
+	e.current = otelog.NoopLogExporter()
+	e.mx.Unlock()
+
+	err := current.Shutdown(ctx)
+
+	drained := make(chan struct{})
+	go func() {
+		e.draining.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return err
+	case <-ctx.Done():
+		return errors.Join(err, ctx.Err())
+	}
+}
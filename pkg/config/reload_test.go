@@ -0,0 +1,116 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/atuleu/otelog"
+)
+
+func fileConfig(t *testing.T, path string) *Config {
+	config, err := Load(strings.NewReader(fmt.Sprintf(`
+exporters:
+  - type: file
+    path: %s
+    batch_timeout: 1h
+`, path)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return config
+}
+
+func countRecords(t *testing.T, path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil && os.IsNotExist(err) == false {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return strings.Count(string(data), `"body"`)
+}
+
+func TestReloadableExporter_Reload(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.jsonl"), filepath.Join(dir, "second.jsonl")
+
+	exporter, err := NewReloadableExporter(fileConfig(t, first))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	logger := otelog.NewLoggerProvider(exporter).Logger("reload", "")
+	ctx := context.Background()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				logger.Emit(ctx, otelog.NewRecord(otelog.SeverityInfo, "record"))
+			}
+		}()
+	}
+	if err := exporter.Reload(fileConfig(t, second)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	wg.Wait()
+
+	bad := &Config{Exporters: []ExporterConfig{{Type: "file", Path: filepath.Join(dir, "missing", "bad.jsonl")}}}
+	if err := exporter.Reload(bad); err == nil {
+		t.Errorf("expected an error for an invalid pipeline")
+	}
+	logger.Emit(ctx, otelog.NewRecord(otelog.SeverityInfo, "record"))
+
+	if err := exporter.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if total := countRecords(t, first) + countRecords(t, second); total != 1001 {
+		t.Errorf("exported %d records, wants 1001", total)
+	}
+	if countRecords(t, second) == 0 {
+		t.Errorf("no records exported after the reload")
+	}
+}
+
+func TestReloadableExporter_WatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	output := filepath.Join(dir, "output.jsonl")
+	write := func(document string) {
+		if err := os.WriteFile(path, []byte(document), 0644); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	write(fmt.Sprintf("exporters: [{type: file, path: %s}]\n", filepath.Join(dir, "initial.jsonl")))
+
+	config, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	exporter, err := NewReloadableExporter(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go exporter.WatchFile(ctx, path, 5*time.Millisecond)
+
+	write(fmt.Sprintf("exporters: [{type: file, path: %s, sync: true}]\n", output))
+	logger := otelog.NewLoggerProvider(exporter).Logger("reload", "")
+	deadline := time.Now().Add(5 * time.Second)
+	for countRecords(t, output) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("configuration was not reloaded")
+		}
+		logger.Emit(ctx, otelog.NewRecord(otelog.SeverityInfo, "record"))
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	exporter.Shutdown(context.Background())
+}
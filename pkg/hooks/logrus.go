@@ -6,11 +6,13 @@
 package hooks
 
 import (
+	"context"
 	"sort"
 
 	"github.com/atuleu/otelog"
 	"github.com/atuleu/otelog/internal/utils"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 
 	"go.opentelemetry.io/otel/trace"
 	common "go.opentelemetry.io/proto/otlp/common/v1"
@@ -18,16 +20,35 @@ import (
 )
 
 type logrusHook struct {
+	logrusOptions
 	exporter otelog.LogExporter
-	levels   []logrus.Level
 }
 
 func (l *logrusHook) Levels() []logrus.Level {
-	return l.levels
+	return addLevels(append([]logrus.Level(nil), l.levels...), l.spanEventLevels)
 }
 
 func (l *logrusHook) Fire(entry *logrus.Entry) error {
-	l.exporter.Export(reportFromLogrus(entry))
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	record := reportFromLogrus(entry)
+	otelog.MergeContextAttributes(ctx, record)
+	for _, b := range l.baggage {
+		b.Merge(ctx, record)
+	}
+	if slices.Contains(l.spanEventLevels, entry.Level) == true {
+		otelog.AddSpanEvent(ctx, record, l.spanErrorStatus)
+	}
+	if slices.Contains(l.levels, entry.Level) == false {
+		return nil
+	}
+	if exporter, ok := l.exporter.(otelog.FlushableLogExporter); ok == true {
+		exporter.ExportContext(ctx, record)
+	} else {
+		l.exporter.Export(record)
+	}
 	return nil
 }
 
@@ -38,13 +59,16 @@ func (l *logrusHook) Fire(entry *logrus.Entry) error {
 //
 // If a context.Context containing a valid otel.SpanContext is
 // provided to the logrus.Entry, the exported LogRecord will be
-// automatically linked with the span.
+// automatically linked with the span. With WithSpanEvents(), entries
+// are also added as events of this span.
+//
+// Attributes set with otelog.ContextWithAttributes() on the context
+// are added to the exported LogRecord. Fields of the entry take
+// precedence over them.
 func NewLogrusHook(options ...LogrusOption) logrus.Hook {
-	opts := newLogrusOptions(options...)
-
 	return &logrusHook{
-		levels:   ([]logrus.Level)(opts),
-		exporter: otelog.GetLogExporter(),
+		logrusOptions: newLogrusOptions(options...),
+		exporter:      otelog.GetLogExporter(),
 	}
 }
 
@@ -116,3 +140,11 @@ func mapLogrusSeverity(level logrus.Level) (logs.SeverityNumber, string) {
 	}
 	return v.value, v.name
 }
+
+// WithLogrus adds a hook created with NewLogrusHook() to the standard
+// logrus logger, once otelog.Setup() installed the global LogExporter.
+func WithLogrus(options ...LogrusOption) otelog.SetupOption {
+	return otelog.WithSetupCallback(func() {
+		logrus.AddHook(NewLogrusHook(options...))
+	})
+}
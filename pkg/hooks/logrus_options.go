@@ -3,11 +3,17 @@ package hooks
 import (
 	"sort"
 
+	"github.com/atuleu/otelog"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/exp/slices"
 )
 
-type logrusOptions []logrus.Level
+type logrusOptions struct {
+	levels          []logrus.Level
+	spanEventLevels []logrus.Level
+	spanErrorStatus bool
+	baggage         []otelog.BaggageAttributes
+}
 
 type logrusOptionApplyFunc func(opts logrusOptions) logrusOptions
 
@@ -24,6 +30,50 @@ func (f logrusOptionApplyFunc) apply(opts logrusOptions) logrusOptions {
 // example `logrus.WarnLevel` will enable `logrus.WarnLevel`
 // `logrus.ErrorLevel` `logrus.FatalLevel` and `logrus.PanicLevel`
 func FromLogrusLevel(level logrus.Level) LogrusOption {
+	return WithLogrusLevels(levelsFrom(level))
+}
+
+// WithLogrusLevels enables all provided levels for the hook.
+func WithLogrusLevels(levels []logrus.Level) LogrusOption {
+	return logrusOptionApplyFunc(func(opts logrusOptions) logrusOptions {
+		opts.levels = addLevels(opts.levels, levels)
+		return opts
+	})
+}
+
+// WithSpanEvents also adds entries from the specified level as events
+// of the span found in their context, independently of the levels
+// enabled for export. See otelog.AddSpanEvent().
+func WithSpanEvents(level logrus.Level) LogrusOption {
+	return logrusOptionApplyFunc(func(opts logrusOptions) logrusOptions {
+		opts.spanEventLevels = addLevels(opts.spanEventLevels, levelsFrom(level))
+		return opts
+	})
+}
+
+// WithSpanErrorStatus sets the status of the span found in the
+// context of error, fatal and panic entries added as span events to
+// codes.Error.
+func WithSpanErrorStatus() LogrusOption {
+	return logrusOptionApplyFunc(func(opts logrusOptions) logrusOptions {
+		opts.spanErrorStatus = true
+		return opts
+	})
+}
+
+// WithBaggage copies the members of the W3C baggage of the entry
+// context selected by b into the exported LogRecord. Fields of the
+// entry and attributes set with otelog.ContextWithAttributes() take
+// precedence over them.
+func WithBaggage(b otelog.BaggageAttributes) LogrusOption {
+	return logrusOptionApplyFunc(func(opts logrusOptions) logrusOptions {
+		opts.baggage = append(opts.baggage, b)
+		return opts
+	})
+}
+
+// levelsFrom returns level and all more severe levels.
+func levelsFrom(level logrus.Level) []logrus.Level {
 	levels := make([]logrus.Level, 0, len(logrus.AllLevels))
 	add := false
 	for i := range logrus.AllLevels {
@@ -35,26 +85,21 @@ func FromLogrusLevel(level logrus.Level) LogrusOption {
 		levels = append(levels, l)
 		add = true
 	}
-
-	return WithLogrusLevels(levels)
+	return levels
 }
 
-// WithLogrusLevels enables all provided levels for the hook.
-func WithLogrusLevels(levels []logrus.Level) LogrusOption {
-	return logrusOptionApplyFunc(func(opts logrusOptions) logrusOptions {
-
-		for _, level := range levels {
-			if slices.Contains(opts, level) == true {
-				continue
-			}
-			opts = append(opts, level)
+// addLevels adds levels to set, sorted from the least severe.
+func addLevels(set, levels []logrus.Level) []logrus.Level {
+	for _, level := range levels {
+		if slices.Contains(set, level) == true {
+			continue
 		}
-		sort.Slice(opts, func(i, j int) bool {
-			return opts[i] > opts[j]
-		})
-
-		return opts
+		set = append(set, level)
+	}
+	sort.Slice(set, func(i, j int) bool {
+		return set[i] > set[j]
 	})
+	return set
 }
 
 func newLogrusOptions(options ...LogrusOption) logrusOptions {
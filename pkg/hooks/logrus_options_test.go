@@ -29,7 +29,7 @@ func TestFromLogrusLevel(t *testing.T) {
 	}
 
 	for _, d := range testdata {
-		opts := newLogrusOptions(FromLogrusLevel(d.Level))
+		opts := newLogrusOptions(FromLogrusLevel(d.Level)).levels
 		if len(opts) != len(d.Expected) {
 			t.Errorf("mismatched size=%d for level %s. Expected: %d",
 				len(opts), d.Level, len(d.Expected))
@@ -54,3 +54,18 @@ func TestFromLogrusLevel(t *testing.T) {
 	}
 
 }
+
+func TestWithSpanEvents(t *testing.T) {
+	hook := NewLogrusHook(FromLogrusLevel(logrus.WarnLevel), WithSpanEvents(logrus.DebugLevel))
+	levels := hook.Levels()
+	expected := []logrus.Level{logrus.DebugLevel, logrus.InfoLevel, logrus.WarnLevel,
+		logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel}
+	if len(levels) != len(expected) {
+		t.Fatalf("Levels() = %v, wants %v", levels, expected)
+	}
+	for i, l := range expected {
+		if levels[i] != l {
+			t.Errorf("Levels()[%d] = %s, wants %s", i, levels[i], l)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package otelog
+
+import (
+	"container/list"
+	"context"
+	"hash/maphash"
+	"math"
+	"sync"
+	"time"
+
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// RateLimitKey selects the fields of a record used to group records
+// in the same rate limiting bucket.
+type RateLimitKey struct {
+	// Body groups records by body.
+	Body bool
+	// Severity groups records by severity number.
+	Severity bool
+	// Attributes groups records by the value of these attributes.
+	Attributes []string
+}
+
+type rateLimitOptions struct {
+	rate       float64
+	burst      int
+	key        RateLimitKey
+	maxBuckets int
+}
+
+// RateLimitOption is an option for NewRateLimitProcessor().
+type RateLimitOption interface {
+	apply(opts *rateLimitOptions)
+}
+
+type rateLimitOptionFunc func(opts *rateLimitOptions)
+
+func (f rateLimitOptionFunc) apply(opts *rateLimitOptions) {
+	f(opts)
+}
+
+// WithRateLimit sets the number of records per second refilled in
+// every bucket, and the maximal number of records in a bucket. A
+// burst below 1 is replaced by the rate rounded up, so at least one
+// record can pass. It defaults to 10 records per second with a burst
+// of 100 records.
+func WithRateLimit(recordsPerSecond float64, burst int) RateLimitOption {
+	return rateLimitOptionFunc(func(opts *rateLimitOptions) {
+		opts.rate = recordsPerSecond
+		opts.burst = burst
+		if burst < 1 {
+			opts.burst = int(math.Max(1, math.Ceil(recordsPerSecond)))
+		}
+	})
+}
+
+// WithRateLimitKey sets the fields used to group records in
+// buckets. It defaults to the body and severity of records.
+func WithRateLimitKey(key RateLimitKey) RateLimitOption {
+	return rateLimitOptionFunc(func(opts *rateLimitOptions) {
+		opts.key = key
+	})
+}
+
+// WithMaxRateLimitBuckets sets the maximal number of buckets. When
+// reached, the least recently used bucket is discarded. Defaults to
+// 1024.
+func WithMaxRateLimitBuckets(n int) RateLimitOption {
+	return rateLimitOptionFunc(func(opts *rateLimitOptions) {
+		opts.maxBuckets = n
+	})
+}
+
+func newRateLimitOptions(options ...RateLimitOption) rateLimitOptions {
+	res := rateLimitOptions{
+		rate:       10,
+		burst:      100,
+		key:        RateLimitKey{Body: true, Severity: true},
+		maxBuckets: 1024,
+	}
+	for _, o := range options {
+		o.apply(&res)
+	}
+	if res.maxBuckets < 1 {
+		res.maxBuckets = 1
+	}
+	return res
+}
+
+type tokenBucket struct {
+	key    uint64
+	tokens float64
+	last   time.Time
+}
+
+type rateLimitProcessor struct {
+	chainedProcessor
+	rateLimitOptions
+
+	mx      sync.Mutex
+	seed    maphash.Seed
+	buckets map[uint64]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+// NewRateLimitProcessor creates a LogProcessor that limits the rate
+// of records forwarded to next. Records are grouped in buckets by a
+// RateLimitKey, and each bucket is an independent token bucket.
+// Records exceeding their bucket rate are dropped.
+func NewRateLimitProcessor(next LogProcessor, options ...RateLimitOption) LogProcessor {
+	return &rateLimitProcessor{
+		chainedProcessor: chainedProcessor{next},
+		rateLimitOptions: newRateLimitOptions(options...),
+		seed:             maphash.MakeSeed(),
+		buckets:          make(map[uint64]*list.Element),
+		lru:              list.New(),
+		now:              time.Now,
+	}
+}
+
+// WithRateLimiter adds a LogProcessor that rate limits records. See
+// NewRateLimitProcessor().
+func WithRateLimiter(options ...RateLimitOption) LogExporterOption {
+	return WithLogProcessor(func(next LogProcessor) LogProcessor {
+		return NewRateLimitProcessor(next, options...)
+	})
+}
+
+func (p *rateLimitProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	if p.allow(p.bucketKey(record)) == false {
+		return nil
+	}
+	return p.next.OnEmit(ctx, record)
+}
+
+func (p *rateLimitProcessor) bucketKey(record *logs.LogRecord) uint64 {
+	var h maphash.Hash
+	h.SetSeed(p.seed)
+	if p.key.Severity {
+		h.WriteString(record.SeverityNumber.String())
+	}
+	h.WriteByte(0)
+	if p.key.Body {
+		h.WriteString(valueString(record.Body))
+	}
+	for _, k := range p.key.Attributes {
+		h.WriteByte(0)
+		if kv := findAttribute(record.Attributes, k); kv != nil {
+			h.WriteString(valueString(kv.Value))
+		}
+	}
+	return h.Sum64()
+}
+
+func (p *rateLimitProcessor) allow(key uint64) bool {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	now := p.now()
+	var bucket *tokenBucket
+	if e, ok := p.buckets[key]; ok == true {
+		p.lru.MoveToFront(e)
+		bucket = e.Value.(*tokenBucket)
+		bucket.tokens += now.Sub(bucket.last).Seconds() * p.rate
+		if bucket.tokens > float64(p.burst) {
+			bucket.tokens = float64(p.burst)
+		}
+		bucket.last = now
+	} else {
+		if p.lru.Len() >= p.maxBuckets {
+			oldest := p.lru.Back()
+			p.lru.Remove(oldest)
+			delete(p.buckets, oldest.Value.(*tokenBucket).key)
+		}
+		bucket = &tokenBucket{key: key, tokens: float64(p.burst), last: now}
+		p.buckets[key] = p.lru.PushFront(bucket)
+	}
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens -= 1
+	return true
+}
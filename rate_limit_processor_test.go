@@ -0,0 +1,79 @@
+package otelog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestRateLimitProcessor(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewRateLimitProcessor(last,
+		WithRateLimit(1, 2),
+		WithRateLimitKey(RateLimitKey{Attributes: []string{"component"}}),
+	).(*rateLimitProcessor)
+	now := time.Unix(0, 0)
+	processor.now = func() time.Time { return now }
+
+	emit := func(component string) {
+		processor.OnEmit(context.Background(), &logs.LogRecord{
+			Body: stringValue("connection refused"),
+			Attributes: []*common.KeyValue{
+				{Key: "component", Value: stringValue(component)},
+			},
+		})
+	}
+
+	for i := 0; i < 5; i++ {
+		emit("db")
+	}
+	emit("cache")
+	if n := len(last.Records()); n != 3 {
+		t.Errorf("len(records) = %d, wants 3", n)
+	}
+
+	now = now.Add(1500 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		emit("db")
+	}
+	if n := len(last.Records()); n != 4 {
+		t.Errorf("len(records) = %d, wants 4", n)
+	}
+}
+
+func TestRateLimitProcessor_maxBuckets(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewRateLimitProcessor(last,
+		WithRateLimit(0, 1),
+		WithMaxRateLimitBuckets(2),
+	).(*rateLimitProcessor)
+
+	for _, body := range []string{"a", "b", "c", "a"} {
+		processor.OnEmit(context.Background(), &logs.LogRecord{Body: stringValue(body)})
+	}
+
+	if n := processor.lru.Len(); n != 2 {
+		t.Errorf("len(buckets) = %d, wants 2", n)
+	}
+	// the bucket for "a" was evicted, so its second record is kept.
+	if n := len(last.Records()); n != 4 {
+		t.Errorf("len(records) = %d, wants 4", n)
+	}
+}
+
+func TestRateLimitProcessor_defaultBurst(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewRateLimitProcessor(last, WithRateLimit(2.5, 0)).(*rateLimitProcessor)
+	now := time.Unix(0, 0)
+	processor.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		processor.OnEmit(context.Background(), &logs.LogRecord{Body: stringValue("a")})
+	}
+	if n := len(last.Records()); n != 3 {
+		t.Errorf("len(records) = %d, wants 3", n)
+	}
+}
@@ -0,0 +1,61 @@
+package otelog
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// PanicFlushTimeout is how long RecoverAndReport() waits for the
+// global LogExporter to flush before panicking again.
+var PanicFlushTimeout = 5 * time.Second
+
+// RecoverAndReport reports a panic as a FATAL record, with the
+// exception.type, exception.message and exception.stacktrace
+// attributes, linked to the span in ctx. It then flushes the global
+// LogExporter, for at most PanicFlushTimeout, and panics again with
+// the same value. It must be deferred directly:
+//
+//	func worker(ctx context.Context) {
+//		defer otelog.RecoverAndReport(ctx)
+//		...
+//	}
+func RecoverAndReport(ctx context.Context) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	reportPanic(ctx, r, debug.Stack())
+	panic(r)
+}
+
+// Go runs f in a new goroutine, reporting its panic with
+// RecoverAndReport().
+func Go(ctx context.Context, f func(ctx context.Context)) {
+	go func() {
+		defer RecoverAndReport(ctx)
+		f(ctx)
+	}()
+}
+
+func reportPanic(ctx context.Context, r interface{}, stack []byte) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	message := fmt.Sprint(r)
+
+	record := NewRecord(SeverityFatal, "panic: "+message).WithAttributes(
+		semconv.ExceptionType(fmt.Sprintf("%T", r)),
+		semconv.ExceptionMessage(message),
+		semconv.ExceptionStacktrace(string(stack)),
+		semconv.ExceptionEscaped(true),
+	)
+	GetLoggerProvider().Logger("github.com/atuleu/otelog", "").Emit(ctx, record)
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), PanicFlushTimeout)
+	defer cancel()
+	forceFlush(GetLogExporter(), flushCtx)
+}
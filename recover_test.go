@@ -0,0 +1,74 @@
+package otelog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestRecoverAndReport(t *testing.T) {
+	exporter := &recordingExporter{}
+	SetLogExporter(exporter)
+	defer SetLogExporter(NoopLogExporter())
+
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+	ctx, span := tp.Tracer("test").Start(context.Background(), "worker")
+	defer span.End()
+
+	failure := errors.New("worker failed")
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		defer RecoverAndReport(ctx)
+		panic(failure)
+	}()
+
+	if recovered != failure {
+		t.Errorf("recovered %v, wants the original panic %v", recovered, failure)
+	}
+	records := exporter.Records()
+	if len(records) != 1 {
+		t.Fatalf("got %d records, wants 1", len(records))
+	}
+	record := records[0]
+	if record.SeverityNumber != logs.SeverityNumber_SEVERITY_NUMBER_FATAL {
+		t.Errorf("severity = %s, wants FATAL", record.SeverityNumber)
+	}
+	traceID := span.SpanContext().TraceID()
+	if bytes.Equal(record.TraceId, traceID[:]) == false {
+		t.Errorf("trace ID = %x, wants %s", record.TraceId, traceID)
+	}
+	if got := findAttribute(record.Attributes, "exception.type").GetValue().GetStringValue(); got != "*errors.errorString" {
+		t.Errorf("exception.type = %q, wants %q", got, "*errors.errorString")
+	}
+	if got := findAttribute(record.Attributes, "exception.message").GetValue().GetStringValue(); got != "worker failed" {
+		t.Errorf("exception.message = %q, wants %q", got, "worker failed")
+	}
+	stack := findAttribute(record.Attributes, "exception.stacktrace").GetValue().GetStringValue()
+	if strings.Contains(stack, "TestRecoverAndReport") == false {
+		t.Errorf("exception.stacktrace does not contain the panicking function:\n%s", stack)
+	}
+	if exporter.flushed != 1 {
+		t.Errorf("exporter flushed %d times, wants 1", exporter.flushed)
+	}
+}
+
+func TestRecoverAndReport_noPanic(t *testing.T) {
+	exporter := &recordingExporter{}
+	SetLogExporter(exporter)
+	defer SetLogExporter(NoopLogExporter())
+
+	func() {
+		defer RecoverAndReport(context.Background())
+	}()
+
+	if len(exporter.Records()) != 0 || exporter.flushed != 0 {
+		t.Errorf("unexpected report without panic")
+	}
+}
@@ -0,0 +1,378 @@
+package otelog
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// RedactionCountKey is the attribute added to records that had
+// values redacted. Its value is the number of redacted values.
+const RedactionCountKey = "otelog.redaction.count"
+
+// RedactionDetector detects sensitive values in strings.
+type RedactionDetector struct {
+	// Name of the detector.
+	Name string
+	// Pattern matches candidate values.
+	Pattern *regexp.Regexp
+	// Validate, if not nil, is called for each match of Pattern to
+	// confirm it is a sensitive value.
+	Validate func(match string) bool
+}
+
+// EmailDetector detects email addresses.
+func EmailDetector() RedactionDetector {
+	return RedactionDetector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	}
+}
+
+// CreditCardDetector detects payment card numbers, that may be
+// separated by spaces or dashes, and are valid according to the Luhn
+// algorithm.
+func CreditCardDetector() RedactionDetector {
+	return RedactionDetector{
+		Name:     "credit_card",
+		Pattern:  regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		Validate: luhnValid,
+	}
+}
+
+// JWTDetector detects JSON Web Tokens.
+func JWTDetector() RedactionDetector {
+	return RedactionDetector{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`),
+	}
+}
+
+// BearerTokenDetector detects bearer tokens, as found in HTTP
+// Authorization headers.
+func BearerTokenDetector() RedactionDetector {
+	return RedactionDetector{
+		Name:    "bearer_token",
+		Pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+	}
+}
+
+// AWSKeyDetector detects AWS access key IDs.
+func AWSKeyDetector() RedactionDetector {
+	return RedactionDetector{
+		Name:    "aws_key",
+		Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AIDA|AROA)[0-9A-Z]{16}\b`),
+	}
+}
+
+// IPv4Detector detects IPv4 addresses.
+func IPv4Detector() RedactionDetector {
+	return RedactionDetector{
+		Name:    "ipv4",
+		Pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`),
+		Validate: func(match string) bool {
+			return net.ParseIP(match) != nil
+		},
+	}
+}
+
+// IPv6Detector detects IPv6 addresses. To not match identifiers like
+// std::vector or a::b, addresses must have at least one group of four
+// hexadecimal digits, like 2001:db8::1 or fe80::1.
+func IPv6Detector() RedactionDetector {
+	return RedactionDetector{
+		Name:     "ipv6",
+		Pattern:  regexp.MustCompile(`(?i)\b(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}\b`),
+		Validate: validIPv6,
+	}
+}
+
+var fullIPv6Group = regexp.MustCompile(`(?i)(?:^|:)[0-9a-f]{4}(?::|$)`)
+
+func validIPv6(match string) bool {
+	ip := net.ParseIP(match)
+	if ip == nil || ip.To4() != nil {
+		return false
+	}
+	return fullIPv6Group.MatchString(match)
+}
+
+// DefaultRedactionDetectors returns all built-in RedactionDetector.
+func DefaultRedactionDetectors() []RedactionDetector {
+	return []RedactionDetector{
+		EmailDetector(),
+		CreditCardDetector(),
+		JWTDetector(),
+		BearerTokenDetector(),
+		AWSKeyDetector(),
+		IPv4Detector(),
+		IPv6Detector(),
+	}
+}
+
+func luhnValid(s string) bool {
+	sum := 0
+	digits := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// RedactionMode defines how sensitive values are redacted.
+type RedactionMode int
+
+const (
+	// RedactMask replaces every character of sensitive values with
+	// '*'.
+	RedactMask RedactionMode = iota
+	// RedactRemove removes sensitive values. Attributes with a
+	// denied key are removed from the record.
+	RedactRemove
+	// RedactHMAC replaces sensitive values with their keyed
+	// HMAC-SHA256, so they can still be correlated. See
+	// WithRedactionHMACKey().
+	RedactHMAC
+)
+
+type redactionOptions struct {
+	detectors []RedactionDetector
+	// patterns are the custom detectors, used in addition to
+	// detectors.
+	patterns   []RedactionDetector
+	deniedKeys map[string]bool
+	mode       RedactionMode
+	hmacKey    []byte
+}
+
+// RedactionOption is an option for NewRedactionProcessor().
+type RedactionOption interface {
+	apply(opts *redactionOptions)
+}
+
+type redactionOptionFunc func(opts *redactionOptions)
+
+func (f redactionOptionFunc) apply(opts *redactionOptions) {
+	f(opts)
+}
+
+// WithRedactionDetectors sets the RedactionDetector to use instead of
+// DefaultRedactionDetectors(). The patterns added with
+// WithRedactionPattern() are kept, whatever the order of the options.
+func WithRedactionDetectors(detectors ...RedactionDetector) RedactionOption {
+	return redactionOptionFunc(func(opts *redactionOptions) {
+		opts.detectors = detectors
+	})
+}
+
+// WithRedactionPattern adds a custom RedactionDetector for values
+// matching re.
+func WithRedactionPattern(name string, re *regexp.Regexp) RedactionOption {
+	return redactionOptionFunc(func(opts *redactionOptions) {
+		opts.patterns = append(opts.patterns, RedactionDetector{Name: name, Pattern: re})
+	})
+}
+
+// WithRedactedKeys redacts the whole value of any attribute which
+// key is in keys. Keys are compared case insensitively.
+func WithRedactedKeys(keys ...string) RedactionOption {
+	return redactionOptionFunc(func(opts *redactionOptions) {
+		for _, k := range keys {
+			opts.deniedKeys[strings.ToLower(k)] = true
+		}
+	})
+}
+
+// WithRedactionMode sets how sensitive values are redacted. Defaults
+// to RedactMask. RedactHMAC requires a key set with
+// WithRedactionHMACKey(), without it values are masked.
+func WithRedactionMode(mode RedactionMode) RedactionOption {
+	return redactionOptionFunc(func(opts *redactionOptions) {
+		opts.mode = mode
+	})
+}
+
+// WithRedactionHMACKey sets the mode to RedactHMAC, with key used to
+// compute the HMAC of sensitive values.
+func WithRedactionHMACKey(key []byte) RedactionOption {
+	return redactionOptionFunc(func(opts *redactionOptions) {
+		opts.mode = RedactHMAC
+		opts.hmacKey = key
+	})
+}
+
+func newRedactionOptions(options ...RedactionOption) redactionOptions {
+	res := redactionOptions{
+		detectors:  DefaultRedactionDetectors(),
+		deniedKeys: make(map[string]bool),
+		mode:       RedactMask,
+	}
+	for _, o := range options {
+		o.apply(&res)
+	}
+	res.detectors = append(append([]RedactionDetector(nil), res.detectors...), res.patterns...)
+	if res.mode == RedactHMAC && len(res.hmacKey) == 0 {
+		// an HMAC without key is a plain hash, which can be
+		// reversed with a dictionary of emails or IP addresses.
+		otel.Handle(errors.New("otelog: redaction HMAC mode requires a key, masking values instead"))
+		res.mode = RedactMask
+	}
+	return res
+}
+
+type redactionProcessor struct {
+	chainedProcessor
+	redactionOptions
+}
+
+// NewRedactionProcessor creates a LogProcessor that redacts sensitive
+// values found in the body and string attribute values of records,
+// before forwarding them to next. By default, it uses
+// DefaultRedactionDetectors() and masks the sensitive values. The
+// number of redacted values is reported in the RedactionCountKey
+// attribute.
+func NewRedactionProcessor(next LogProcessor, options ...RedactionOption) LogProcessor {
+	return &redactionProcessor{
+		chainedProcessor: chainedProcessor{next},
+		redactionOptions: newRedactionOptions(options...),
+	}
+}
+
+// WithRedaction adds a LogProcessor that redacts sensitive
+// values. See NewRedactionProcessor().
+func WithRedaction(options ...RedactionOption) LogExporterOption {
+	return WithLogProcessor(func(next LogProcessor) LogProcessor {
+		return NewRedactionProcessor(next, options...)
+	})
+}
+
+func (p *redactionProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	count := p.redactValue(record.Body)
+
+	attributes := record.Attributes[:0]
+	for _, kv := range record.Attributes {
+		if p.deniedKeys[strings.ToLower(kv.Key)] == false {
+			count += p.redactValue(kv.Value)
+			attributes = append(attributes, kv)
+			continue
+		}
+		count += 1
+		if p.mode == RedactRemove {
+			continue
+		}
+		kv.Value = stringValue(p.redact(valueString(kv.Value)))
+		attributes = append(attributes, kv)
+	}
+	record.Attributes = attributes
+
+	if count > 0 {
+		record.Attributes = append(record.Attributes, &common.KeyValue{
+			Key:   RedactionCountKey,
+			Value: intValue(int64(count)),
+		})
+	}
+
+	return p.next.OnEmit(ctx, record)
+}
+
+// redactValue redacts any string in v, and returns the number of
+// redacted values.
+func (p *redactionProcessor) redactValue(v *common.AnyValue) int {
+	switch vv := v.GetValue().(type) {
+	case *common.AnyValue_StringValue:
+		var count int
+		vv.StringValue, count = p.redactString(vv.StringValue)
+		return count
+	case *common.AnyValue_ArrayValue:
+		count := 0
+		for _, e := range vv.ArrayValue.GetValues() {
+			count += p.redactValue(e)
+		}
+		return count
+	case *common.AnyValue_KvlistValue:
+		count := 0
+		for _, kv := range vv.KvlistValue.GetValues() {
+			count += p.redactValue(kv.Value)
+		}
+		return count
+	}
+	return 0
+}
+
+type redactionMatch struct {
+	start, end int
+}
+
+// redactString redacts all sensitive values in s.
+func (p *redactionProcessor) redactString(s string) (string, int) {
+	var matches []redactionMatch
+	for _, d := range p.detectors {
+		for _, loc := range d.Pattern.FindAllStringIndex(s, -1) {
+			if d.Validate != nil && d.Validate(s[loc[0]:loc[1]]) == false {
+				continue
+			}
+			matches = append(matches, redactionMatch{loc[0], loc[1]})
+		}
+	}
+	if len(matches) == 0 {
+		return s, 0
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start == matches[j].start {
+			return matches[i].end > matches[j].end
+		}
+		return matches[i].start < matches[j].start
+	})
+
+	var res strings.Builder
+	last, count := 0, 0
+	for _, m := range matches {
+		if m.start < last {
+			// overlaps a previous match
+			continue
+		}
+		res.WriteString(s[last:m.start])
+		res.WriteString(p.redact(s[m.start:m.end]))
+		last = m.end
+		count += 1
+	}
+	res.WriteString(s[last:])
+	return res.String(), count
+}
+
+func (p *redactionProcessor) redact(s string) string {
+	switch p.mode {
+	case RedactRemove:
+		return ""
+	case RedactHMAC:
+		mac := hmac.New(sha256.New, p.hmacKey)
+		mac.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:16])
+	default:
+		return strings.Repeat("*", utf8.RuneCountInString(s))
+	}
+}
@@ -0,0 +1,168 @@
+package otelog
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"sort"
+
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// SamplingRatioKey is the attribute added to sampled records, when
+// their sampling ratio is below one. Backends can use it to
+// extrapolate the actual number of records.
+const SamplingRatioKey = "otelog.sampling.ratio"
+
+type severityRatio struct {
+	min   logs.SeverityNumber
+	ratio float64
+}
+
+type samplingOptions struct {
+	ratios            []severityRatio
+	defaultRatio      float64
+	keepSampledTraces bool
+}
+
+// SamplingOption is an option for NewSamplingProcessor().
+type SamplingOption interface {
+	apply(opts *samplingOptions)
+}
+
+type samplingOptionFunc func(opts *samplingOptions)
+
+func (f samplingOptionFunc) apply(opts *samplingOptions) {
+	f(opts)
+}
+
+// WithSeverityRatio sets the sampling ratio of records with a
+// severity greater or equal to min, up to the next severity set with
+// WithSeverityRatio(). By example, to keep 10% of INFO records, but
+// all WARN and above:
+//
+//	WithSeverityRatio(logs.SeverityNumber_SEVERITY_NUMBER_INFO, 0.1)
+//	WithSeverityRatio(logs.SeverityNumber_SEVERITY_NUMBER_WARN, 1.0)
+func WithSeverityRatio(min logs.SeverityNumber, ratio float64) SamplingOption {
+	return samplingOptionFunc(func(opts *samplingOptions) {
+		opts.ratios = append(opts.ratios, severityRatio{min, clampRatio(ratio)})
+	})
+}
+
+// WithDefaultSamplingRatio sets the sampling ratio of records with a
+// severity below any set with WithSeverityRatio(). Defaults to 1.
+func WithDefaultSamplingRatio(ratio float64) SamplingOption {
+	return samplingOptionFunc(func(opts *samplingOptions) {
+		opts.defaultRatio = clampRatio(ratio)
+	})
+}
+
+// WithSampledTracesKept sets if all records linked to a sampled trace
+// are kept, regardless of their sampling ratio. Defaults to true.
+func WithSampledTracesKept(keep bool) SamplingOption {
+	return samplingOptionFunc(func(opts *samplingOptions) {
+		opts.keepSampledTraces = keep
+	})
+}
+
+func clampRatio(ratio float64) float64 {
+	if ratio < 0 {
+		return 0
+	}
+	if ratio > 1 {
+		return 1
+	}
+	return ratio
+}
+
+func newSamplingOptions(options ...SamplingOption) samplingOptions {
+	res := samplingOptions{
+		defaultRatio:      1.0,
+		keepSampledTraces: true,
+	}
+	for _, o := range options {
+		o.apply(&res)
+	}
+	sort.SliceStable(res.ratios, func(i, j int) bool {
+		return res.ratios[i].min > res.ratios[j].min
+	})
+	return res
+}
+
+type samplingProcessor struct {
+	chainedProcessor
+	samplingOptions
+	random func() float64
+}
+
+// NewSamplingProcessor creates a LogProcessor that probabilistically
+// samples records according to their severity, and forwards the kept
+// records to next.
+//
+// The decision is made from the record TraceId, so all records of a
+// trace are kept or dropped together. Records without a TraceId are
+// randomly sampled.
+func NewSamplingProcessor(next LogProcessor, options ...SamplingOption) LogProcessor {
+	return &samplingProcessor{
+		chainedProcessor: chainedProcessor{next},
+		samplingOptions:  newSamplingOptions(options...),
+		random:           rand.Float64,
+	}
+}
+
+// WithSampling adds a LogProcessor that samples records. See
+// NewSamplingProcessor().
+func WithSampling(options ...SamplingOption) LogExporterOption {
+	return WithLogProcessor(func(next LogProcessor) LogProcessor {
+		return NewSamplingProcessor(next, options...)
+	})
+}
+
+func (p *samplingProcessor) ratio(severity logs.SeverityNumber) float64 {
+	for _, r := range p.ratios {
+		if severity >= r.min {
+			return r.ratio
+		}
+	}
+	return p.defaultRatio
+}
+
+const sampledFlag = 0x01
+
+func (p *samplingProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	hasTrace := len(record.TraceId) == 16
+	if hasTrace && p.keepSampledTraces && record.Flags&sampledFlag != 0 {
+		return p.next.OnEmit(ctx, record)
+	}
+
+	ratio := p.ratio(record.SeverityNumber)
+	if ratio >= 1.0 {
+		return p.next.OnEmit(ctx, record)
+	}
+
+	var keep bool
+	if hasTrace {
+		keep = traceIDSampled(record.TraceId, ratio)
+	} else {
+		keep = p.random() < ratio
+	}
+	if keep == false {
+		return nil
+	}
+
+	record.Attributes = append(record.Attributes, &common.KeyValue{
+		Key:   SamplingRatioKey,
+		Value: &common.AnyValue{Value: &common.AnyValue_DoubleValue{DoubleValue: ratio}},
+	})
+	return p.next.OnEmit(ctx, record)
+}
+
+// traceIDSampled decides from the lower 63 bits of traceID, like the
+// TraceIDRatioBased trace sampler, so a trace kept with a ratio is
+// also kept with any higher ratio.
+func traceIDSampled(traceID []byte, ratio float64) bool {
+	bound := uint64(ratio * (1 << 63))
+	x := binary.BigEndian.Uint64(traceID[8:16]) >> 1
+	return x < bound
+}
@@ -0,0 +1,75 @@
+package otelog
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func traceIDFromUint64(v uint64) []byte {
+	res := make([]byte, 16)
+	binary.BigEndian.PutUint64(res[8:], v)
+	return res
+}
+
+func TestSamplingProcessor_perSeverity(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewSamplingProcessor(last,
+		WithDefaultSamplingRatio(0),
+		WithSeverityRatio(logs.SeverityNumber_SEVERITY_NUMBER_WARN, 1),
+		WithSeverityRatio(logs.SeverityNumber_SEVERITY_NUMBER_INFO, 0.1),
+	).(*samplingProcessor)
+	random := 0.05
+	processor.random = func() float64 { return random }
+
+	emit := func(severity logs.SeverityNumber) {
+		processor.OnEmit(context.Background(), &logs.LogRecord{SeverityNumber: severity})
+	}
+
+	emit(logs.SeverityNumber_SEVERITY_NUMBER_DEBUG)
+	emit(logs.SeverityNumber_SEVERITY_NUMBER_INFO)
+	random = 0.5
+	emit(logs.SeverityNumber_SEVERITY_NUMBER_INFO)
+	emit(logs.SeverityNumber_SEVERITY_NUMBER_ERROR)
+
+	records := last.Records()
+	if len(records) != 2 {
+		t.Fatalf("len(records) = %d, wants 2", len(records))
+	}
+	if kv := findAttribute(records[0].Attributes, SamplingRatioKey); kv == nil || kv.Value.GetDoubleValue() != 0.1 {
+		t.Errorf("expected %s = 0.1 on the INFO record", SamplingRatioKey)
+	}
+	if len(records[1].Attributes) != 0 {
+		t.Errorf("expected no sampling ratio on the ERROR record")
+	}
+}
+
+func TestSamplingProcessor_traceConsistent(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewSamplingProcessor(last, WithDefaultSamplingRatio(0.5)).(*samplingProcessor)
+	processor.random = func() float64 {
+		t.Errorf("random sampling should not be used for records with a TraceId")
+		return 0
+	}
+
+	kept := traceIDFromUint64(1 << 62)
+	dropped := traceIDFromUint64(1 << 63)
+	for i := 0; i < 3; i++ {
+		processor.OnEmit(context.Background(), &logs.LogRecord{TraceId: kept})
+		processor.OnEmit(context.Background(), &logs.LogRecord{TraceId: dropped})
+	}
+	// records of sampled traces are always kept.
+	processor.OnEmit(context.Background(), &logs.LogRecord{TraceId: dropped, Flags: 0x01})
+
+	records := last.Records()
+	if len(records) != 4 {
+		t.Fatalf("len(records) = %d, wants 4", len(records))
+	}
+	for _, r := range records[:3] {
+		if binary.BigEndian.Uint64(r.TraceId[8:]) != 1<<62 {
+			t.Errorf("unexpected record from trace %x", r.TraceId)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package otelog
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	collector "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+)
+
+type setupOptions struct {
+	logOptions   []LogExporterOption
+	traceOptions []sdktrace.TracerProviderOption
+	callbacks    []func()
+}
+
+// SetupOption is an option for Setup().
+type SetupOption interface {
+	apply(opts *setupOptions)
+}
+
+type setupOptionFunc func(opts *setupOptions)
+
+func (f setupOptionFunc) apply(opts *setupOptions) {
+	f(opts)
+}
+
+// WithLogExporterOptions sets the options of the LogExporter. The
+// endpoint, credentials and resource set with these options are also
+// used for traces. Bearer tokens are only sent with traces on the
+// connection dialed by Setup().
+func WithLogExporterOptions(options ...LogExporterOption) SetupOption {
+	return setupOptionFunc(func(opts *setupOptions) {
+		opts.logOptions = append(opts.logOptions, options...)
+	})
+}
+
+// WithTracerProviderOptions adds options to the
+// sdktrace.TracerProvider. Spans are always exported in batches to
+// the collector, with the shared resource.
+func WithTracerProviderOptions(options ...sdktrace.TracerProviderOption) SetupOption {
+	return setupOptionFunc(func(opts *setupOptions) {
+		opts.traceOptions = append(opts.traceOptions, options...)
+	})
+}
+
+// WithSetupCallback calls f once the global TracerProvider and
+// LogExporter are installed. It is meant for integrations of logging
+// libraries, like hooks.WithLogrus().
+func WithSetupCallback(f func()) SetupOption {
+	return setupOptionFunc(func(opts *setupOptions) {
+		opts.callbacks = append(opts.callbacks, f)
+	})
+}
+
+// ShutdownFunc flushes and stops everything installed by Setup().
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup exports traces and logs to a single Open Telemetry collector
+// endpoint. It detects the resource once, dials a single gRPC
+// connection, and installs the global TracerProvider and
+// LogExporter. Only gRPC is supported: it returns an error if the
+// options select OTLP/HTTP, a file or a writer. By example:
+//
+//	shutdown, err := otelog.Setup(ctx,
+//		otelog.WithLogExporterOptions(
+//			otelog.WithEndpoint("localhost:4317"),
+//			otelog.WithInsecure(),
+//			otelog.WithResource(res)),
+//		hooks.WithLogrus(hooks.FromLogrusLevel(logrus.InfoLevel)))
+//	if err != nil {
+//		return err
+//	}
+//	defer shutdown(context.Background())
+//
+// The returned ShutdownFunc shuts the TracerProvider down first, as
+// its span processors may emit records, then the LogExporter, and
+// finally closes the connection.
+func Setup(ctx context.Context, options ...SetupOption) (ShutdownFunc, error) {
+	opts := setupOptions{}
+	for _, o := range options {
+		o.apply(&opts)
+	}
+
+	logOpts := newOtelLogExporterOptions(opts.logOptions...)
+	logOpts.resource = mergeResources(detectDefaultResource(ctx), logOpts.resource)
+
+	if err := checkTokenTransport(logOpts); err != nil {
+		return nil, err
+	}
+	if logOpts.writer != nil || len(logOpts.filePath) > 0 || len(logOpts.httpEndpoint) > 0 {
+		return nil, errors.New("otelog: Setup() only exports to a gRPC endpoint, WithHTTPEndpoint(), WithFile() and WithWriter() are not supported")
+	}
+	if logOpts.conn == nil && len(logOpts.endpoint) == 0 {
+		return nil, errors.New("otelog: Setup() requires WithEndpoint() or WithGRPCConn()")
+	}
+
+	var ownedConn *grpc.ClientConn
+	var client collector.LogsServiceClient
+	var closer io.Closer = multiCloser{}
+	if logOpts.conn != nil {
+		client = grpcLogsClient(logOpts, logOpts.conn)
+	} else {
+		var err error
+		logOpts.conn, err = dial(logOpts)
+		if err != nil {
+			return nil, err
+		}
+		ownedConn = logOpts.conn
+		client, closer = dialedLogsClient(logOpts, ownedConn)
+	}
+	closeConn := func() error {
+		if ownedConn == nil {
+			return closer.Close()
+		}
+		return errors.Join(closer.Close(), ownedConn.Close())
+	}
+
+	traceExporter, err := otlptrace.New(ctx,
+		otlptracegrpc.NewClient(otlptracegrpc.WithGRPCConn(logOpts.conn)))
+	if err != nil {
+		return nil, errors.Join(err, closeConn())
+	}
+
+	traceOptions := append([]sdktrace.TracerProviderOption{
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(logOpts.resource),
+	}, opts.traceOptions...)
+	tracerProvider := sdktrace.NewTracerProvider(traceOptions...)
+	exporter := newOtelExporter(logOpts, client)
+
+	otel.SetTracerProvider(tracerProvider)
+	SetLogExporter(exporter)
+	for _, f := range opts.callbacks {
+		f()
+	}
+
+	return func(ctx context.Context) error {
+		return errors.Join(
+			tracerProvider.Shutdown(ctx),
+			exporter.Shutdown(ctx),
+			closeConn())
+	}, nil
+}
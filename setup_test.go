@@ -0,0 +1,73 @@
+package otelog
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestSetup(t *testing.T) {
+	defer SetLogExporter(NoopLogExporter())
+
+	called := false
+	shutdown, err := Setup(context.Background(),
+		WithLogExporterOptions(WithEndpoint("localhost:4317"), WithInsecure()),
+		WithSetupCallback(func() {
+			called = true
+			if _, ok := GetLogExporter().(*otelExporter); ok == false {
+				t.Errorf("global LogExporter is %T, wants *otelExporter", GetLogExporter())
+			}
+		}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if called == false {
+		t.Errorf("setup callback was not called")
+	}
+	if _, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); ok == false {
+		t.Errorf("global TracerProvider is %T, wants *sdktrace.TracerProvider", otel.GetTracerProvider())
+	}
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("unexpected shutdown error: %s", err)
+	}
+}
+
+func TestSetup_errors(t *testing.T) {
+	testdata := []struct {
+		Name    string
+		Options []LogExporterOption
+	}{
+		{"no endpoint", []LogExporterOption{WithInsecure()}},
+		{"http", []LogExporterOption{WithHTTPEndpoint("http://localhost:4318/v1/logs")}},
+		{"file", []LogExporterOption{WithFile("logs.jsonl")}},
+		{"writer", []LogExporterOption{WithWriter(io.Discard)}},
+	}
+	for _, d := range testdata {
+		if _, err := Setup(context.Background(), WithLogExporterOptions(d.Options...)); err == nil {
+			t.Errorf("%s: expected an error", d.Name)
+		}
+	}
+}
+
+func TestSetup_tokens(t *testing.T) {
+	defer SetLogExporter(NoopLogExporter())
+
+	shutdown, err := Setup(context.Background(),
+		WithLogExporterOptions(WithEndpoint("localhost:4317"), WithInsecure(),
+			WithBearerToken(StaticToken("token")), WithInsecureTokens()))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer shutdown(context.Background())
+
+	// rejected tokens are discarded, like with NewLogExporter().
+	if client := GetLogExporter().(*otelExporter).logClient; client == nil {
+		t.Fatalf("no logs client")
+	} else if _, ok := client.(invalidatingLogsClient); ok == false {
+		t.Errorf("logs client is %T, wants invalidatingLogsClient", client)
+	}
+}
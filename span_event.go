@@ -0,0 +1,49 @@
+package otelog
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// SpanEventSeverityKey is the span event attribute reporting the
+// severity of a mirrored record.
+const SpanEventSeverityKey = "log.severity"
+
+// AddSpanEvent adds record as an event of the span found in ctx, if
+// it is recording. The event is named after the record body, and
+// carries its severity in SpanEventSeverityKey and its
+// attributes. If errorStatus is true, records at or above
+// SEVERITY_NUMBER_ERROR also set the span status to codes.Error.
+//
+// It is meant to be used by integrations of logging libraries, before
+// exporting record.
+func AddSpanEvent(ctx context.Context, record *logs.LogRecord, errorStatus bool) {
+	span := trace.SpanFromContext(ctx)
+	if span.IsRecording() == false {
+		return
+	}
+
+	attributes := make([]attribute.KeyValue, 0, len(record.Attributes)+1)
+	attributes = append(attributes,
+		attribute.String(SpanEventSeverityKey, severityName(record.SeverityNumber)))
+	for _, kv := range record.Attributes {
+		attributes = append(attributes, attributeFromValue(kv.Key, kv.Value))
+	}
+
+	options := []trace.EventOption{trace.WithAttributes(attributes...)}
+	if record.TimeUnixNano != 0 {
+		options = append(options, trace.WithTimestamp(time.Unix(0, int64(record.TimeUnixNano))))
+	}
+
+	body := valueString(record.Body)
+	span.AddEvent(body, options...)
+
+	if errorStatus == true && record.SeverityNumber >= logs.SeverityNumber_SEVERITY_NUMBER_ERROR {
+		span.SetStatus(codes.Error, body)
+	}
+}
@@ -0,0 +1,147 @@
+package otelog
+
+import (
+	"context"
+	"time"
+
+	"github.com/atuleu/otelog/internal/utils"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+type spanEventOptions struct {
+	severity          logs.SeverityNumber
+	exceptionSeverity logs.SeverityNumber
+}
+
+// SpanEventOption is an option for NewSpanEventProcessor().
+type SpanEventOption interface {
+	apply(opts *spanEventOptions)
+}
+
+type spanEventOptionFunc func(opts *spanEventOptions)
+
+func (f spanEventOptionFunc) apply(opts *spanEventOptions) {
+	f(opts)
+}
+
+// WithSpanEventSeverity sets the severity of records converted from
+// span events. Defaults to SEVERITY_NUMBER_INFO.
+func WithSpanEventSeverity(severity logs.SeverityNumber) SpanEventOption {
+	return spanEventOptionFunc(func(opts *spanEventOptions) {
+		opts.severity = severity
+	})
+}
+
+// WithSpanExceptionSeverity sets the severity of records converted
+// from exception span events, as recorded by span.RecordError().
+// Defaults to SEVERITY_NUMBER_ERROR.
+func WithSpanExceptionSeverity(severity logs.SeverityNumber) SpanEventOption {
+	return spanEventOptionFunc(func(opts *spanEventOptions) {
+		opts.exceptionSeverity = severity
+	})
+}
+
+func newSpanEventOptions(options ...SpanEventOption) spanEventOptions {
+	res := spanEventOptions{
+		severity:          logs.SeverityNumber_SEVERITY_NUMBER_INFO,
+		exceptionSeverity: logs.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	}
+	for _, o := range options {
+		o.apply(&res)
+	}
+	return res
+}
+
+type spanEventProcessor struct {
+	spanEventOptions
+	exporter LogExporter
+}
+
+// NewSpanEventProcessor creates a sdktrace.SpanProcessor that exports
+// the events of every ended span as LogRecord to exporter, or to the
+// global LogExporter if exporter is nil.
+//
+// Records are linked to the span, carry the event attributes, and
+// have the event name as body. Events added by AddSpanEvent() are
+// skipped, as their record is already exported. Exception events use the
+// exception.message attribute as body, and a higher severity.
+//
+// Shutting the processor down flushes exporter, but does not shut it
+// down.
+func NewSpanEventProcessor(exporter LogExporter, options ...SpanEventOption) sdktrace.SpanProcessor {
+	return &spanEventProcessor{
+		spanEventOptions: newSpanEventOptions(options...),
+		exporter:         exporter,
+	}
+}
+
+func (p *spanEventProcessor) getExporter() LogExporter {
+	if p.exporter != nil {
+		return p.exporter
+	}
+	return GetLogExporter()
+}
+
+func (p *spanEventProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {}
+
+func (p *spanEventProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	events := s.Events()
+	if len(events) == 0 {
+		return
+	}
+	ctx := contextWithInstrumentationScope(context.Background(), s.InstrumentationScope())
+	exporter := p.getExporter()
+	for _, e := range events {
+		if mirroredRecord(e) == true {
+			continue
+		}
+		exportContext(exporter, ctx, p.recordFromEvent(s, e))
+	}
+}
+
+// mirroredRecord returns true if e was added by AddSpanEvent(), from
+// a record that is exported on its own.
+func mirroredRecord(e sdktrace.Event) bool {
+	for _, kv := range e.Attributes {
+		if kv.Key == SpanEventSeverityKey {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *spanEventProcessor) recordFromEvent(s sdktrace.ReadOnlySpan, e sdktrace.Event) *logs.LogRecord {
+	spanContext := s.SpanContext()
+	traceID, spanID := spanContext.TraceID(), spanContext.SpanID()
+	record := &logs.LogRecord{
+		TimeUnixNano:           uint64(e.Time.UnixNano()),
+		ObservedTimeUnixNano:   uint64(time.Now().UnixNano()),
+		SeverityNumber:         p.severity,
+		Body:                   stringValue(e.Name),
+		Attributes:             utils.KeyValues(e.Attributes),
+		DroppedAttributesCount: uint32(e.DroppedAttributeCount),
+		TraceId:                traceID[:],
+		SpanId:                 spanID[:],
+		Flags:                  uint32(spanContext.TraceFlags()),
+	}
+
+	if e.Name == semconv.ExceptionEventName {
+		record.SeverityNumber = p.exceptionSeverity
+		if kv := findAttribute(record.Attributes, string(semconv.ExceptionMessageKey)); kv != nil {
+			record.Body = kv.Value
+		}
+	}
+	record.SeverityText = severityName(record.SeverityNumber)
+
+	return record
+}
+
+func (p *spanEventProcessor) ForceFlush(ctx context.Context) error {
+	return forceFlush(p.getExporter(), ctx)
+}
+
+func (p *spanEventProcessor) Shutdown(ctx context.Context) error {
+	return p.ForceFlush(ctx)
+}
@@ -0,0 +1,112 @@
+package otelog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestSpanEventProcessor(t *testing.T) {
+	exporter := &recordingExporter{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(NewSpanEventProcessor(exporter)))
+	defer tp.Shutdown(context.Background())
+
+	_, span := tp.Tracer("legacy").Start(context.Background(), "operation")
+	span.AddEvent("cache miss", trace.WithAttributes(attribute.String("key", "user:42")))
+	span.RecordError(errors.New("connection refused"))
+	if n := len(exporter.Records()); n != 0 {
+		t.Fatalf("len(records) = %d before span end, wants 0", n)
+	}
+	span.End()
+
+	records := exporter.Records()
+	if len(records) != 2 {
+		t.Fatalf("len(records) = %d, wants 2", len(records))
+	}
+
+	spanContext := span.SpanContext()
+	traceID, spanID := spanContext.TraceID(), spanContext.SpanID()
+	for _, r := range records {
+		if string(r.TraceId) != string(traceID[:]) || string(r.SpanId) != string(spanID[:]) {
+			t.Errorf("record %v is not linked to the span", r.Body)
+		}
+	}
+	if scope := InstrumentationScopeFromContext(exporter.contexts[0]); scope.Name != "legacy" {
+		t.Errorf("scope = %q, wants %q", scope.Name, "legacy")
+	}
+
+	if body := records[0].Body.GetStringValue(); body != "cache miss" {
+		t.Errorf("body = %q, wants %q", body, "cache miss")
+	}
+	if records[0].SeverityNumber != logs.SeverityNumber_SEVERITY_NUMBER_INFO {
+		t.Errorf("severity = %s, wants INFO", records[0].SeverityNumber)
+	}
+	if kv := findAttribute(records[0].Attributes, "key"); kv.GetValue().GetStringValue() != "user:42" {
+		t.Errorf("attribute key = %v, wants %q", kv, "user:42")
+	}
+
+	if body := records[1].Body.GetStringValue(); body != "connection refused" {
+		t.Errorf("body = %q, wants %q", body, "connection refused")
+	}
+	if records[1].SeverityNumber != logs.SeverityNumber_SEVERITY_NUMBER_ERROR ||
+		records[1].SeverityText != "ERROR" {
+		t.Errorf("severity = %s %q, wants ERROR", records[1].SeverityNumber, records[1].SeverityText)
+	}
+	if findAttribute(records[1].Attributes, "exception.type") == nil {
+		t.Errorf("missing exception.type attribute")
+	}
+}
+
+func TestSpanEventProcessor_keepsSpanScope(t *testing.T) {
+	processor := &recordingProcessor{}
+	exporter := &otelExporter{
+		processor:            processor,
+		instrumentationScope: instrumentation.Scope{Name: "exporter"},
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(NewSpanEventProcessor(exporter)))
+	defer tp.Shutdown(context.Background())
+
+	_, span := tp.Tracer("legacy").Start(context.Background(), "operation")
+	span.AddEvent("cache miss")
+	span.End()
+	exporter.Export(newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "direct"))
+
+	if len(processor.contexts) != 2 {
+		t.Fatalf("len(records) = %d, wants 2", len(processor.contexts))
+	}
+	for i, expected := range []string{"legacy", "exporter"} {
+		if scope := InstrumentationScopeFromContext(processor.contexts[i]); scope.Name != expected {
+			t.Errorf("scope = %q, wants %q", scope.Name, expected)
+		}
+	}
+}
+
+func TestSpanEventProcessor_skipsMirroredRecords(t *testing.T) {
+	exporter := &recordingExporter{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(NewSpanEventProcessor(exporter)))
+	defer tp.Shutdown(context.Background())
+
+	ctx, span := tp.Tracer("app").Start(context.Background(), "operation")
+	// as the logrus hook does with WithSpanEvents().
+	record := newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_WARN, "slow query")
+	AddSpanEvent(ctx, record, false)
+	exporter.ExportContext(ctx, record)
+	span.AddEvent("cache miss")
+	span.End()
+
+	records := exporter.Records()
+	if len(records) != 2 {
+		t.Fatalf("len(records) = %d, wants 2", len(records))
+	}
+	for i, expected := range []string{"slow query", "cache miss"} {
+		if body := records[i].Body.GetStringValue(); body != expected {
+			t.Errorf("records[%d] body = %q, wants %q", i, body, expected)
+		}
+	}
+}
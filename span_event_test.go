@@ -0,0 +1,50 @@
+package otelog
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestAddSpanEvent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "operation")
+	AddSpanEvent(ctx, newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_WARN, "slow query",
+		&common.KeyValue{Key: "duration_ms", Value: intValue(1200)}), true)
+	AddSpanEvent(ctx, newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_ERROR, "query failed"), false)
+	AddSpanEvent(ctx, newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_ERROR, "retry failed"), true)
+	AddSpanEvent(context.Background(), newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_ERROR, "no span"), true)
+	span.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("len(spans) = %d, wants 1", len(ended))
+	}
+	events := ended[0].Events()
+	if len(events) != 3 {
+		t.Fatalf("len(events) = %d, wants 3", len(events))
+	}
+	if events[0].Name != "slow query" {
+		t.Errorf("event name = %q, wants %q", events[0].Name, "slow query")
+	}
+	attributes := map[string]string{}
+	for _, kv := range events[0].Attributes {
+		attributes[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attributes[SpanEventSeverityKey] != "WARN" || attributes["duration_ms"] != "1200" {
+		t.Errorf("event attributes = %v", attributes)
+	}
+
+	status := ended[0].Status()
+	if status.Code != codes.Error || status.Description != "retry failed" {
+		t.Errorf("status = %v, wants Error with description %q", status, "retry failed")
+	}
+}
@@ -0,0 +1,346 @@
+package otelog
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+type tailOptions struct {
+	bufferBelow   logs.SeverityNumber
+	trigger       logs.SeverityNumber
+	bufferSize    int
+	ttl           time.Duration
+	maxTraces     int
+	maxRecords    int
+	meterProvider metric.MeterProvider
+}
+
+// TailOption is an option for NewTailProcessor().
+type TailOption interface {
+	apply(opts *tailOptions)
+}
+
+type tailOptionFunc func(opts *tailOptions)
+
+func (f tailOptionFunc) apply(opts *tailOptions) {
+	f(opts)
+}
+
+// WithTailBufferBelow sets the severity below which records are
+// buffered. Defaults to SEVERITY_NUMBER_INFO, so TRACE and DEBUG
+// records are buffered.
+func WithTailBufferBelow(severity logs.SeverityNumber) TailOption {
+	return tailOptionFunc(func(opts *tailOptions) {
+		opts.bufferBelow = severity
+	})
+}
+
+// WithTailTrigger sets the severity at or above which the buffered
+// records of a trace are exported. Defaults to SEVERITY_NUMBER_ERROR.
+func WithTailTrigger(severity logs.SeverityNumber) TailOption {
+	return tailOptionFunc(func(opts *tailOptions) {
+		opts.trigger = severity
+	})
+}
+
+// WithTailBufferSize sets the maximal number of records buffered for
+// a trace. When reached, the oldest records are discarded. Defaults
+// to 100.
+func WithTailBufferSize(size int) TailOption {
+	return tailOptionFunc(func(opts *tailOptions) {
+		opts.bufferSize = size
+	})
+}
+
+// WithTailTTL sets the time the records of a trace are buffered,
+// before being discarded. Defaults to 30 seconds.
+func WithTailTTL(ttl time.Duration) TailOption {
+	return tailOptionFunc(func(opts *tailOptions) {
+		opts.ttl = ttl
+	})
+}
+
+// WithTailMaxTraces sets the maximal number of traces buffered. When
+// reached, the oldest trace is discarded. Defaults to 1000.
+func WithTailMaxTraces(n int) TailOption {
+	return tailOptionFunc(func(opts *tailOptions) {
+		opts.maxTraces = n
+	})
+}
+
+// WithTailMaxRecords sets the maximal number of records buffered for
+// all traces. When reached, the oldest trace is discarded. Defaults
+// to 10000.
+func WithTailMaxRecords(n int) TailOption {
+	return tailOptionFunc(func(opts *tailOptions) {
+		opts.maxRecords = n
+	})
+}
+
+// WithTailMeterProvider sets the MeterProvider used to report the
+// number of rescued and discarded records. Defaults to the global
+// MeterProvider.
+func WithTailMeterProvider(mp metric.MeterProvider) TailOption {
+	return tailOptionFunc(func(opts *tailOptions) {
+		opts.meterProvider = mp
+	})
+}
+
+func newTailOptions(options ...TailOption) tailOptions {
+	res := tailOptions{
+		bufferBelow: logs.SeverityNumber_SEVERITY_NUMBER_INFO,
+		trigger:     logs.SeverityNumber_SEVERITY_NUMBER_ERROR,
+		bufferSize:  100,
+		ttl:         30 * time.Second,
+		maxTraces:   1000,
+		maxRecords:  10000,
+	}
+	for _, o := range options {
+		o.apply(&res)
+	}
+	if res.meterProvider == nil {
+		res.meterProvider = otel.GetMeterProvider()
+	}
+	if res.bufferSize < 1 {
+		res.bufferSize = 1
+	}
+	return res
+}
+
+// tailTrace holds the buffered records of a trace.
+type tailTrace struct {
+	traceID   string
+	element   *list.Element
+	timer     *time.Timer
+	triggered bool
+
+	// ring buffer of records, and their context. It grows up to
+	// capacity.
+	records  []*logs.LogRecord
+	contexts []context.Context
+	capacity int
+	start    int
+	size     int
+}
+
+func (t *tailTrace) push(ctx context.Context, record *logs.LogRecord) (discarded bool) {
+	if len(t.records) < t.capacity {
+		t.records = append(t.records, record)
+		t.contexts = append(t.contexts, ctx)
+		t.size += 1
+		return false
+	}
+	if t.size == len(t.records) {
+		t.records[t.start] = record
+		t.contexts[t.start] = ctx
+		t.start = (t.start + 1) % len(t.records)
+		return true
+	}
+	idx := (t.start + t.size) % len(t.records)
+	t.records[idx] = record
+	t.contexts[idx] = ctx
+	t.size += 1
+	return false
+}
+
+type tailProcessor struct {
+	chainedProcessor
+	tailOptions
+
+	mx       sync.Mutex
+	traces   map[string]*tailTrace
+	lru      *list.List
+	buffered int
+
+	rescued      atomic.Int64
+	discarded    atomic.Int64
+	registration metric.Registration
+}
+
+// NewTailProcessor creates a LogProcessor that buffers low severity
+// records per TraceId, and only forwards them to next if a record at
+// or above a trigger severity is emitted for the same trace. Once a
+// trace is triggered, its low severity records are forwarded until
+// its TTL expires. Otherwise, the buffered records are discarded
+// after the TTL. Low severity records without a TraceId are
+// discarded.
+//
+// The number of rescued and discarded records are reported with the
+// otelog.tail.rescued and otelog.tail.discarded counters.
+func NewTailProcessor(next LogProcessor, options ...TailOption) LogProcessor {
+	res := &tailProcessor{
+		chainedProcessor: chainedProcessor{next},
+		tailOptions:      newTailOptions(options...),
+		traces:           make(map[string]*tailTrace),
+		lru:              list.New(),
+	}
+	res.registerMetrics()
+	return res
+}
+
+// WithTailBuffering adds a LogProcessor that buffers low severity
+// records until an error occurs in their trace. See
+// NewTailProcessor().
+func WithTailBuffering(options ...TailOption) LogExporterOption {
+	return WithLogProcessor(func(next LogProcessor) LogProcessor {
+		return NewTailProcessor(next, options...)
+	})
+}
+
+func (p *tailProcessor) registerMetrics() {
+	meter := p.meterProvider.Meter("github.com/atuleu/otelog")
+	rescued, err := meter.Int64ObservableCounter("otelog.tail.rescued",
+		metric.WithDescription("Number of buffered log records exported after a trigger"),
+		metric.WithUnit("{record}"))
+	if err != nil {
+		otel.Handle(err)
+		return
+	}
+	discarded, err := meter.Int64ObservableCounter("otelog.tail.discarded",
+		metric.WithDescription("Number of buffered log records discarded"),
+		metric.WithUnit("{record}"))
+	if err != nil {
+		otel.Handle(err)
+		return
+	}
+	p.registration, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(rescued, p.rescued.Load())
+		o.ObserveInt64(discarded, p.discarded.Load())
+		return nil
+	}, rescued, discarded)
+	if err != nil {
+		otel.Handle(err)
+	}
+}
+
+func (p *tailProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	if record.SeverityNumber >= p.trigger {
+		if err := p.rescue(record.TraceId); err != nil {
+			otel.Handle(err)
+		}
+		return p.next.OnEmit(ctx, record)
+	}
+
+	if record.SeverityNumber >= p.bufferBelow {
+		return p.next.OnEmit(ctx, record)
+	}
+
+	if len(record.TraceId) == 0 {
+		p.discarded.Add(1)
+		return nil
+	}
+
+	p.mx.Lock()
+	trace := p.getOrCreate(string(record.TraceId))
+	if trace.triggered == true {
+		p.mx.Unlock()
+		return p.next.OnEmit(ctx, record)
+	}
+	if trace.push(detachContext(ctx), record) == true {
+		p.discarded.Add(1)
+	} else {
+		p.buffered += 1
+	}
+	p.enforceLimits()
+	p.mx.Unlock()
+	return nil
+}
+
+// getOrCreate returns the tailTrace for traceID. It must be called
+// with the lock held.
+func (p *tailProcessor) getOrCreate(traceID string) *tailTrace {
+	if trace, ok := p.traces[traceID]; ok == true {
+		return trace
+	}
+	trace := &tailTrace{
+		traceID:  traceID,
+		capacity: p.bufferSize,
+	}
+	trace.element = p.lru.PushBack(trace)
+	trace.timer = time.AfterFunc(p.ttl, func() { p.expire(trace) })
+	p.traces[traceID] = trace
+	return trace
+}
+
+// enforceLimits discards the oldest traces until the global limits
+// are respected. It must be called with the lock held.
+func (p *tailProcessor) enforceLimits() {
+	for p.lru.Len() > 0 && (p.lru.Len() > p.maxTraces || p.buffered > p.maxRecords) {
+		p.remove(p.lru.Front().Value.(*tailTrace))
+	}
+}
+
+// remove removes and discards trace. It must be called with the lock
+// held.
+func (p *tailProcessor) remove(trace *tailTrace) {
+	trace.timer.Stop()
+	p.lru.Remove(trace.element)
+	delete(p.traces, trace.traceID)
+	p.buffered -= trace.size
+	p.discarded.Add(int64(trace.size))
+	trace.size = 0
+}
+
+func (p *tailProcessor) expire(trace *tailTrace) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if p.traces[trace.traceID] != trace {
+		return
+	}
+	p.remove(trace)
+}
+
+// rescue forwards the buffered records of traceID, and marks the
+// trace as triggered.
+func (p *tailProcessor) rescue(traceID []byte) error {
+	if len(traceID) == 0 {
+		return nil
+	}
+	p.mx.Lock()
+	trace := p.getOrCreate(string(traceID))
+	trace.triggered = true
+	records := make([]*logs.LogRecord, 0, trace.size)
+	contexts := make([]context.Context, 0, trace.size)
+	for i := 0; i < trace.size; i++ {
+		idx := (trace.start + i) % len(trace.records)
+		records = append(records, trace.records[idx])
+		contexts = append(contexts, trace.contexts[idx])
+	}
+	p.buffered -= trace.size
+	trace.records, trace.contexts = nil, nil
+	trace.size, trace.start = 0, 0
+	p.enforceLimits()
+	p.mx.Unlock()
+
+	p.rescued.Add(int64(len(records)))
+	var err error
+	for i, r := range records {
+		if rerr := p.next.OnEmit(contexts[i], r); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// Shutdown discards all buffered records, stops reporting the
+// metrics, and shuts next down.
+func (p *tailProcessor) Shutdown(ctx context.Context) error {
+	p.mx.Lock()
+	for p.lru.Len() > 0 {
+		p.remove(p.lru.Front().Value.(*tailTrace))
+	}
+	p.mx.Unlock()
+	if p.registration != nil {
+		if err := p.registration.Unregister(); err != nil {
+			otel.Handle(err)
+		}
+	}
+	return p.next.Shutdown(ctx)
+}
@@ -0,0 +1,139 @@
+package otelog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func newTailTestRecord(severity logs.SeverityNumber, traceID byte, body string) *logs.LogRecord {
+	res := &logs.LogRecord{SeverityNumber: severity, Body: stringValue(body)}
+	if traceID != 0 {
+		res.TraceId = []byte{traceID}
+	}
+	return res
+}
+
+func TestTailProcessor(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewTailProcessor(last, WithTailBufferSize(2)).(*tailProcessor)
+	ctx := context.Background()
+
+	debug := logs.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	processor.OnEmit(ctx, newTailTestRecord(debug, 1, "a"))
+	processor.OnEmit(ctx, newTailTestRecord(debug, 1, "b"))
+	processor.OnEmit(ctx, newTailTestRecord(debug, 1, "c"))
+	processor.OnEmit(ctx, newTailTestRecord(debug, 2, "d"))
+	processor.OnEmit(ctx, newTailTestRecord(debug, 0, "e"))
+	processor.OnEmit(ctx, newTailTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, 2, "f"))
+
+	if n := len(last.Records()); n != 1 {
+		t.Fatalf("len(records) = %d before trigger, wants 1", n)
+	}
+
+	processor.OnEmit(ctx, newTailTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_ERROR, 1, "g"))
+	processor.OnEmit(ctx, newTailTestRecord(debug, 1, "h"))
+
+	var bodies string
+	for _, r := range last.Records() {
+		bodies += r.Body.GetStringValue()
+	}
+	if bodies != "fbcgh" {
+		t.Errorf("exported records = %q, wants %q", bodies, "fbcgh")
+	}
+
+	if n := processor.rescued.Load(); n != 2 {
+		t.Errorf("rescued = %d, wants 2", n)
+	}
+	// "a" was overwritten and "e" has no TraceId.
+	if n := processor.discarded.Load(); n != 2 {
+		t.Errorf("discarded = %d, wants 2", n)
+	}
+
+	processor.Shutdown(ctx)
+	if n := processor.discarded.Load(); n != 3 {
+		t.Errorf("discarded = %d after shutdown, wants 3", n)
+	}
+}
+
+func TestTailProcessor_limits(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewTailProcessor(last,
+		WithTailMaxRecords(3),
+		WithTailTTL(10*time.Millisecond),
+	).(*tailProcessor)
+	ctx := context.Background()
+
+	for i := byte(1); i <= 4; i++ {
+		processor.OnEmit(ctx, newTailTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_DEBUG, i, "a"))
+	}
+	if n := processor.discarded.Load(); n != 1 {
+		t.Errorf("discarded = %d, wants 1", n)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if n := processor.discarded.Load(); n != 4 {
+		t.Errorf("discarded = %d after TTL, wants 4", n)
+	}
+	if n := len(last.Records()); n != 0 {
+		t.Errorf("len(records) = %d, wants 0", n)
+	}
+}
+
+func TestTailProcessor_rescueEnforcesLimits(t *testing.T) {
+	last := &recordingProcessor{}
+	processor := NewTailProcessor(last, WithTailMaxTraces(2)).(*tailProcessor)
+	ctx := context.Background()
+
+	for i := byte(1); i <= 4; i++ {
+		processor.OnEmit(ctx, newTailTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_ERROR, i, "error"))
+	}
+	processor.mx.Lock()
+	n := len(processor.traces)
+	processor.mx.Unlock()
+	if n != 2 {
+		t.Errorf("len(traces) = %d, wants 2", n)
+	}
+}
+
+func TestTailProcessor_shutdownUnregistersMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	ctx := context.Background()
+	processor := NewTailProcessor(&recordingProcessor{}, WithTailMeterProvider(mp))
+	discard := func() {
+		processor.OnEmit(ctx, newTailTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_DEBUG, 0, "a"))
+	}
+
+	discarded := func() int64 {
+		var data metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &data); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var res int64
+		for _, s := range data.ScopeMetrics {
+			for _, m := range s.Metrics {
+				if sum, ok := m.Data.(metricdata.Sum[int64]); ok == true && m.Name == "otelog.tail.discarded" {
+					for _, p := range sum.DataPoints {
+						res += p.Value
+					}
+				}
+			}
+		}
+		return res
+	}
+
+	discard()
+	if n := discarded(); n != 1 {
+		t.Fatalf("otelog.tail.discarded = %d, wants 1", n)
+	}
+	processor.Shutdown(ctx)
+	discard()
+	if n := discarded(); n != 1 {
+		t.Errorf("otelog.tail.discarded = %d after shutdown, wants 1 as it is not observed anymore", n)
+	}
+}
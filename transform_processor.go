@@ -0,0 +1,366 @@
+package otelog
+
+import (
+	"context"
+	"fmt"
+
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// valueExpression is the argument of a transformation: either a
+// literal value or a path of the record.
+type valueExpression struct {
+	literal *common.AnyValue
+	path    *recordPath
+}
+
+func (e valueExpression) evaluate(ctx context.Context, record *logs.LogRecord) *common.AnyValue {
+	if e.path != nil {
+		return e.path.get(ctx, record)
+	}
+	return e.literal
+}
+
+// settable returns true if the path can be modified by a
+// transformation.
+func (p recordPath) settable() bool {
+	switch p.field {
+	case pathBody, pathSeverityNumber, pathSeverityText:
+		return true
+	case pathAttributes:
+		return len(p.key) > 0
+	}
+	return false
+}
+
+// set sets the value at the path of record. Values with an
+// incompatible type are ignored.
+func (p recordPath) set(record *logs.LogRecord, value *common.AnyValue) {
+	switch p.field {
+	case pathBody:
+		record.Body = value
+	case pathSeverityNumber:
+		if v, ok := value.GetValue().(*common.AnyValue_IntValue); ok == true {
+			record.SeverityNumber = logs.SeverityNumber(v.IntValue)
+		}
+	case pathSeverityText:
+		if v, ok := value.GetValue().(*common.AnyValue_StringValue); ok == true {
+			record.SeverityText = v.StringValue
+		}
+	case pathAttributes:
+		if kv := findAttribute(record.Attributes, p.key); kv != nil {
+			kv.Value = value
+			return
+		}
+		record.Attributes = append(record.Attributes, &common.KeyValue{Key: p.key, Value: value})
+	}
+}
+
+func deleteAttributes(record *logs.LogRecord, match func(key string) bool) {
+	attributes := record.Attributes[:0]
+	for _, kv := range record.Attributes {
+		if match(kv.Key) == false {
+			attributes = append(attributes, kv)
+		}
+	}
+	record.Attributes = attributes
+}
+
+type transformStatement struct {
+	apply     func(ctx context.Context, record *logs.LogRecord)
+	condition LogFilter
+}
+
+// LogTransform is a parsed list of transformation statements. See
+// ParseLogTransform().
+type LogTransform struct {
+	statements []transformStatement
+}
+
+// ParseLogTransform parses transformation statements, using a syntax
+// modeled on the Open Telemetry collector OTTL. Each statement calls
+// a function, and may be followed by a where clause, with a
+// condition as described in ParseLogFilter(). Available functions
+// are:
+//
+//   - set(path, value): sets path to a literal value or the value of
+//     another path.
+//   - delete_key(attributes, "key"): deletes an attribute.
+//   - delete_matching_keys(attributes, "regexp"): deletes all
+//     attributes with a key matching regexp.
+//   - rename(attributes["key"], "new_key"): renames an attribute.
+//   - replace_pattern(path, "regexp", "replacement"): replaces all
+//     matches of regexp in a string value. The replacement may
+//     reference capture groups with $1.
+//
+// By example:
+//
+//	rename(attributes["err"], "exception.message")
+//	set(severity_number, SEVERITY_NUMBER_WARN) where IsMatch(body, "timeout")
+//	delete_matching_keys(attributes, "^internal\\.")
+//
+// Statements are applied in order.
+func ParseLogTransform(statements ...string) (*LogTransform, error) {
+	res := &LogTransform{}
+	for i, s := range statements {
+		statement, err := parseTransformStatement(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid statement %d %q: %w", i+1, s, err)
+		}
+		res.statements = append(res.statements, statement)
+	}
+	return res, nil
+}
+
+// Apply applies all statements to record, emitted within ctx.
+func (t *LogTransform) Apply(ctx context.Context, record *logs.LogRecord) {
+	for _, s := range t.statements {
+		if s.condition != nil && s.condition.Match(ctx, record) == false {
+			continue
+		}
+		s.apply(ctx, record)
+	}
+}
+
+func parseTransformStatement(statement string) (transformStatement, error) {
+	p, err := newExpressionParser(statement)
+	if err != nil {
+		return transformStatement{}, err
+	}
+	res, err := p.parseFunction()
+	if err != nil {
+		return transformStatement{}, err
+	}
+	if p.accept(tokenIdentifier, "where") {
+		if res.condition, err = p.parseCondition(); err != nil {
+			return transformStatement{}, err
+		}
+	}
+	return res, p.expectEOF()
+}
+
+func (p *expressionParser) parseFunction() (transformStatement, error) {
+	name := p.next()
+	if name.kind != tokenIdentifier {
+		return transformStatement{}, p.errorf(name, "expected a function, got %s", name)
+	}
+
+	var parse func() (transformStatement, error)
+	switch name.text {
+	case "set":
+		parse = p.parseSet
+	case "delete_key":
+		parse = p.parseDeleteKey
+	case "delete_matching_keys":
+		parse = p.parseDeleteMatchingKeys
+	case "rename":
+		parse = p.parseRename
+	case "replace_pattern":
+		parse = p.parseReplacePattern
+	default:
+		return transformStatement{}, p.errorf(name, "unknown function %s", name)
+	}
+
+	if err := p.expect(tokenPunctuation, "("); err != nil {
+		return transformStatement{}, err
+	}
+	res, err := parse()
+	if err != nil {
+		return transformStatement{}, err
+	}
+	return res, p.expect(tokenPunctuation, ")")
+}
+
+func (p *expressionParser) parseSettablePath() (recordPath, error) {
+	t := p.peek()
+	path, err := p.parsePath()
+	if err != nil {
+		return recordPath{}, err
+	}
+	if path.settable() == false {
+		return recordPath{}, p.errorf(t, "path %s cannot be modified", path)
+	}
+	return path, nil
+}
+
+func (p *expressionParser) parseValueExpression() (valueExpression, error) {
+	t := p.peek()
+	if t.kind == tokenIdentifier {
+		switch t.text {
+		case pathBody, pathSeverityNumber, pathSeverityText, pathAttributes, pathScopeName, pathScopeVersion:
+			path, err := p.parsePath()
+			return valueExpression{path: &path}, err
+		}
+	}
+	value, err := p.parseLiteral()
+	if err != nil {
+		return valueExpression{}, err
+	}
+	if value == nil {
+		return valueExpression{}, p.errorf(t, "cannot set a value to nil, use delete_key()")
+	}
+	return valueExpression{literal: value}, nil
+}
+
+func (p *expressionParser) parseStringArgument(what string) (string, error) {
+	if err := p.expect(tokenPunctuation, ","); err != nil {
+		return "", err
+	}
+	t := p.next()
+	if t.kind != tokenString {
+		return "", p.errorf(t, "expected %s string, got %s", what, t)
+	}
+	return t.value, nil
+}
+
+func (p *expressionParser) parseAttributesArgument() error {
+	if t := p.next(); t.kind != tokenIdentifier || t.text != pathAttributes {
+		return p.errorf(t, "expected attributes, got %s", t)
+	}
+	return nil
+}
+
+func (p *expressionParser) parseSet() (transformStatement, error) {
+	path, err := p.parseSettablePath()
+	if err != nil {
+		return transformStatement{}, err
+	}
+	if err := p.expect(tokenPunctuation, ","); err != nil {
+		return transformStatement{}, err
+	}
+	valueToken := p.peek()
+	value, err := p.parseValueExpression()
+	if err != nil {
+		return transformStatement{}, err
+	}
+	if path.field == pathSeverityNumber && value.literal != nil {
+		if _, ok := value.literal.Value.(*common.AnyValue_IntValue); ok == false {
+			return transformStatement{}, p.errorf(valueToken,
+				"severity_number must be set to a SEVERITY_NUMBER_* constant or an integer, got %s",
+				valueToken)
+		}
+	}
+	return transformStatement{
+		apply: func(ctx context.Context, record *logs.LogRecord) {
+			if v := value.evaluate(ctx, record); v != nil {
+				// v may be another value of the record, or the
+				// literal shared by all records.
+				path.set(record, proto.Clone(v).(*common.AnyValue))
+			}
+		},
+	}, nil
+}
+
+func (p *expressionParser) parseDeleteKey() (transformStatement, error) {
+	if err := p.parseAttributesArgument(); err != nil {
+		return transformStatement{}, err
+	}
+	key, err := p.parseStringArgument("an attribute key")
+	if err != nil {
+		return transformStatement{}, err
+	}
+	return transformStatement{
+		apply: func(ctx context.Context, record *logs.LogRecord) {
+			deleteAttributes(record, func(k string) bool { return k == key })
+		},
+	}, nil
+}
+
+func (p *expressionParser) parseDeleteMatchingKeys() (transformStatement, error) {
+	if err := p.parseAttributesArgument(); err != nil {
+		return transformStatement{}, err
+	}
+	if err := p.expect(tokenPunctuation, ","); err != nil {
+		return transformStatement{}, err
+	}
+	re, err := p.parseRegexp()
+	if err != nil {
+		return transformStatement{}, err
+	}
+	return transformStatement{
+		apply: func(ctx context.Context, record *logs.LogRecord) {
+			deleteAttributes(record, re.MatchString)
+		},
+	}, nil
+}
+
+func (p *expressionParser) parseRename() (transformStatement, error) {
+	t := p.peek()
+	from, err := p.parsePath()
+	if err != nil {
+		return transformStatement{}, err
+	}
+	if from.field != pathAttributes {
+		return transformStatement{}, p.errorf(t, "only attributes can be renamed, got %s", from)
+	}
+	to, err := p.parseStringArgument("an attribute key")
+	if err != nil {
+		return transformStatement{}, err
+	}
+	return transformStatement{
+		apply: func(ctx context.Context, record *logs.LogRecord) {
+			kv := findAttribute(record.Attributes, from.key)
+			if kv == nil || from.key == to {
+				return
+			}
+			deleteAttributes(record, func(k string) bool { return k == to })
+			kv.Key = to
+		},
+	}, nil
+}
+
+func (p *expressionParser) parseReplacePattern() (transformStatement, error) {
+	path, err := p.parseSettablePath()
+	if err != nil {
+		return transformStatement{}, err
+	}
+	if err := p.expect(tokenPunctuation, ","); err != nil {
+		return transformStatement{}, err
+	}
+	re, err := p.parseRegexp()
+	if err != nil {
+		return transformStatement{}, err
+	}
+	replacement, err := p.parseStringArgument("a replacement")
+	if err != nil {
+		return transformStatement{}, err
+	}
+	return transformStatement{
+		apply: func(ctx context.Context, record *logs.LogRecord) {
+			v, ok := path.get(ctx, record).GetValue().(*common.AnyValue_StringValue)
+			if ok == false {
+				return
+			}
+			path.set(record, stringValue(re.ReplaceAllString(v.StringValue, replacement)))
+		},
+	}, nil
+}
+
+type transformProcessor struct {
+	chainedProcessor
+	transform *LogTransform
+}
+
+// NewTransformProcessor creates a LogProcessor that applies transform
+// to every record, before forwarding them to next.
+func NewTransformProcessor(next LogProcessor, transform *LogTransform) LogProcessor {
+	return &transformProcessor{
+		chainedProcessor: chainedProcessor{next},
+		transform:        transform,
+	}
+}
+
+// WithTransform adds a LogProcessor that transforms records. See
+// NewTransformProcessor() and ParseLogTransform().
+func WithTransform(transform *LogTransform) LogExporterOption {
+	return WithLogProcessor(func(next LogProcessor) LogProcessor {
+		return NewTransformProcessor(next, transform)
+	})
+}
+
+func (p *transformProcessor) OnEmit(ctx context.Context, record *logs.LogRecord) error {
+	p.transform.Apply(ctx, record)
+	return p.next.OnEmit(ctx, record)
+}
@@ -0,0 +1,124 @@
+package otelog
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	logs "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestLogTransform(t *testing.T) {
+	transform, err := ParseLogTransform(
+		`rename(attributes["err"], "exception.message")`,
+		`set(attributes["enduser.id"], attributes["user"])`,
+		`delete_key(attributes, "user")`,
+		`set(severity_number, SEVERITY_NUMBER_WARN) where IsMatch(body, "timeout") and severity_number < SEVERITY_NUMBER_WARN`,
+		`set(severity_text, "WARN") where severity_number == SEVERITY_NUMBER_WARN`,
+		`delete_matching_keys(attributes, "^internal\\.")`,
+		`replace_pattern(body, "token=[^ ]+", "token=***")`,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	record := newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "request timeout token=abcd after 3s",
+		&common.KeyValue{Key: "err", Value: stringValue("context deadline exceeded")},
+		&common.KeyValue{Key: "user", Value: stringValue("alice")},
+		&common.KeyValue{Key: "internal.shard", Value: intValue(3)},
+		&common.KeyValue{Key: "http.method", Value: stringValue("GET")})
+
+	last := &recordingProcessor{}
+	NewTransformProcessor(last, transform).OnEmit(context.Background(), record)
+
+	records := last.Records()
+	if len(records) != 1 {
+		t.Fatalf("len(records) = %d, wants 1", len(records))
+	}
+	record = records[0]
+	if record.SeverityNumber != logs.SeverityNumber_SEVERITY_NUMBER_WARN {
+		t.Errorf("severity number = %s, wants WARN", record.SeverityNumber)
+	}
+	if record.SeverityText != "WARN" {
+		t.Errorf("severity text = %q, wants %q", record.SeverityText, "WARN")
+	}
+	if body := record.Body.GetStringValue(); body != "request timeout token=*** after 3s" {
+		t.Errorf("body = %q, wants %q", body, "request timeout token=*** after 3s")
+	}
+
+	expected := map[string]string{
+		"exception.message": "context deadline exceeded",
+		"enduser.id":        "alice",
+		"http.method":       "GET",
+	}
+	if len(record.Attributes) != len(expected) {
+		t.Errorf("attributes = %v, wants %v", record.Attributes, expected)
+	}
+	for k, v := range expected {
+		kv := findAttribute(record.Attributes, k)
+		if kv == nil || kv.Value.GetStringValue() != v {
+			t.Errorf("attribute %s = %v, wants %q", k, kv, v)
+		}
+	}
+}
+
+func TestParseLogTransform_errors(t *testing.T) {
+	testdata := []struct {
+		Statement string
+		Expected  string
+	}{
+		{`drop()`, `column 1: unknown function "drop"`},
+		{`set(instrumentation_scope.name, "foo")`, `column 5: path instrumentation_scope.name cannot be modified`},
+		{`set(severity_number, "WARN")`, `column 22: severity_number must be set to a SEVERITY_NUMBER_* constant`},
+		{`set(body, nil)`, `column 11: cannot set a value to nil`},
+		{`delete_key(body, "a")`, `column 12: expected attributes, got "body"`},
+		{`rename(body, "message")`, `column 8: only attributes can be renamed`},
+		{`replace_pattern(body, "(", "")`, `column 23: invalid regular expression`},
+		{`set(body, "a") where`, `column 21: expected a path, got end of expression`},
+		{`set(body, "a"`, `column 14: expected ")"`},
+	}
+
+	for _, d := range testdata {
+		_, err := ParseLogTransform(`set(body, "valid")`, d.Statement)
+		if err == nil {
+			t.Errorf("expected an error for %s", d.Statement)
+			continue
+		}
+		if strings.HasPrefix(err.Error(), "invalid statement 2") == false {
+			t.Errorf("error for %s = %q, wants it to report statement 2", d.Statement, err)
+		}
+		if strings.Contains(err.Error(), d.Expected) == false {
+			t.Errorf("error for %s = %q, wants it to contain %q", d.Statement, err, d.Expected)
+		}
+	}
+}
+
+func TestLogTransform_setCopiesValue(t *testing.T) {
+	transform, err := ParseLogTransform(
+		`set(attributes["copy"], attributes["original"])`,
+		`set(attributes["literal"], "value")`,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	last := &recordingProcessor{}
+	processor := NewTransformProcessor(last, transform)
+	for i := 0; i < 2; i++ {
+		record := newTestRecord(logs.SeverityNumber_SEVERITY_NUMBER_INFO, "",
+			&common.KeyValue{Key: "original", Value: stringValue("alice")})
+		processor.OnEmit(context.Background(), record)
+	}
+
+	records := last.Records()
+	findAttribute(records[0].Attributes, "original").Value.Value = &common.AnyValue_StringValue{StringValue: "bob"}
+	findAttribute(records[0].Attributes, "literal").Value.Value = &common.AnyValue_StringValue{StringValue: "changed"}
+
+	if v := findAttribute(records[0].Attributes, "copy").Value.GetStringValue(); v != "alice" {
+		t.Errorf("copy = %q, wants %q", v, "alice")
+	}
+	if v := findAttribute(records[1].Attributes, "literal").Value.GetStringValue(); v != "value" {
+		t.Errorf("literal of the second record = %q, wants %q", v, "value")
+	}
+}